@@ -0,0 +1,74 @@
+package pgxtxpool
+
+import "context"
+
+// AfterCommit will register fn to run after transaction in context is committed
+// callbacks run in order they are registered, after CommitTX succeed
+// and they are discarded when transaction is rolled back
+// callback registered inside a savepoint or joined context run after the outermost transaction is committed,
+// and it is discarded when that savepoint is rolled back
+// if context does not carry an active transaction then fn will run immediately
+func (p *Pool) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	conn, ok := p.getTXConnFromContext(ctx)
+	if !ok || conn.tx == nil {
+		fn(ctx)
+		return
+	}
+
+	if conn.owner != nil {
+		conn = conn.owner
+	}
+	conn.addAfterCommit(fn)
+}
+
+// addAfterCommit will add callbacks that run after transaction is committed
+func (c *txConn) addAfterCommit(fns ...func(ctx context.Context)) {
+	c.afterCommitMx.Lock()
+	defer c.afterCommitMx.Unlock()
+	c.afterCommit = append(c.afterCommit, fns...)
+}
+
+// takeAfterCommit will take every callbacks that registered to transaction
+func (c *txConn) takeAfterCommit() []func(ctx context.Context) {
+	c.afterCommitMx.Lock()
+	defer c.afterCommitMx.Unlock()
+	fns := c.afterCommit
+	c.afterCommit = nil
+	return fns
+}
+
+// runAfterCommit will run callbacks of a committed transaction
+// callbacks of a released savepoint are handed over to its parent
+// when a callback panic, remaining callbacks still run
+// then the first panic is re-thrown after transaction is removed from the pool
+func (p *Pool) runAfterCommit(ctx context.Context, conn *txConn) {
+	fns := conn.takeAfterCommit()
+	if len(fns) == 0 {
+		return
+	}
+
+	if conn.kind == TxKindSavepoint {
+		conn.parent.addAfterCommit(fns...)
+		return
+	}
+
+	// context no longer carry the committed transaction
+	// so query inside callback will not use it
+	ctx = p.contextWithoutTxID(ctx)
+
+	var recovered any
+	for _, fn := range fns {
+		func() {
+			defer func() {
+				if r := recover(); r != nil && recovered == nil {
+					recovered = r
+				}
+			}()
+			fn(ctx)
+		}()
+	}
+
+	if recovered != nil {
+		panic(recovered)
+	}
+}
@@ -0,0 +1,151 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestAfterCommit(t *testing.T) {
+	t.Run("should run callbacks in order after commit", func(t *testing.T) {
+		p, _ := newFakePool()
+
+		var calls []string
+		err := p.WithTransaction(context.Background(), func(ctx context.Context) error {
+			p.AfterCommit(ctx, func(ctx context.Context) { calls = append(calls, "first") })
+			p.AfterCommit(ctx, func(ctx context.Context) { calls = append(calls, "second") })
+			if len(calls) != 0 {
+				t.Log("callbacks should not run before commit")
+				t.FailNow()
+			}
+			return nil
+		})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if expCalls := []string{"first", "second"}; !slices.Equal(calls, expCalls) {
+			t.Logf("expected calls %v, got %v", expCalls, calls)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should discard callbacks on rollback", func(t *testing.T) {
+		p, _ := newFakePool()
+
+		var calls []string
+		p.WithTransaction(context.Background(), func(ctx context.Context) error {
+			p.AfterCommit(ctx, func(ctx context.Context) { calls = append(calls, "first") })
+			return errors.New("something went wrong")
+		})
+
+		if len(calls) != 0 {
+			t.Logf("callbacks should be discarded, got %v", calls)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should run immediately without transaction", func(t *testing.T) {
+		p, _ := newFakePool()
+
+		var calls []string
+		p.AfterCommit(context.Background(), func(ctx context.Context) { calls = append(calls, "first") })
+
+		// scope without transaction
+		p.WithTransactionPropagation(context.Background(), PropagationNotSupported, pgx.TxOptions{}, func(ctx context.Context) error {
+			p.AfterCommit(ctx, func(ctx context.Context) { calls = append(calls, "second") })
+			return nil
+		})
+
+		if expCalls := []string{"first", "second"}; !slices.Equal(calls, expCalls) {
+			t.Logf("expected calls %v, got %v", expCalls, calls)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should run savepoint and joined callbacks after outermost commit", func(t *testing.T) {
+		p, _ := newFakePool()
+
+		var calls []string
+		err := p.WithTransaction(context.Background(), func(ctx context.Context) error {
+			// released savepoint
+			p.WithTransaction(ctx, func(ctx context.Context) error {
+				p.AfterCommit(ctx, func(ctx context.Context) { calls = append(calls, "savepoint") })
+				return nil
+			})
+
+			// rolled back savepoint
+			p.WithTransaction(ctx, func(ctx context.Context) error {
+				p.AfterCommit(ctx, func(ctx context.Context) { calls = append(calls, "rolled back") })
+				return errors.New("something went wrong")
+			})
+
+			// joined
+			p.WithTransactionPropagation(ctx, PropagationRequired, pgx.TxOptions{}, func(ctx context.Context) error {
+				p.AfterCommit(ctx, func(ctx context.Context) { calls = append(calls, "joined") })
+				return nil
+			})
+
+			if len(calls) != 0 {
+				t.Log("callbacks should not run before outermost commit")
+				t.FailNow()
+			}
+			return nil
+		})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if expCalls := []string{"savepoint", "joined"}; !slices.Equal(calls, expCalls) {
+			t.Logf("expected calls %v, got %v", expCalls, calls)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should run remaining callbacks and re-panic", func(t *testing.T) {
+		p, _ := newFakePool()
+
+		var calls []string
+		var txCTX context.Context
+		func() {
+			defer func() {
+				if r := recover(); r != "boom" {
+					t.Logf("expected panic boom, got %v", r)
+					t.FailNow()
+				}
+			}()
+			p.WithTransaction(context.Background(), func(ctx context.Context) error {
+				txCTX = ctx
+				p.AfterCommit(ctx, func(ctx context.Context) { panic("boom") })
+				p.AfterCommit(ctx, func(ctx context.Context) {
+					if _, ok := p.getTXFromContext(ctx); ok {
+						t.Log("callback context should not carry committed transaction")
+						t.Fail()
+					}
+					calls = append(calls, "second")
+				})
+				return nil
+			})
+		}()
+
+		if expCalls := []string{"second"}; !slices.Equal(calls, expCalls) {
+			t.Logf("expected calls %v, got %v", expCalls, calls)
+			t.FailNow()
+		}
+
+		if err := p.VerifyTX(txCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if stats := p.TxStats(); stats.Committed != 1 || len(p.ActiveTransactions()) != 0 {
+			t.Logf("registry should be consistent, got stats %+v", stats)
+			t.FailNow()
+		}
+	})
+}
@@ -1,15 +1,54 @@
 package pgxtxpool
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/url"
+	"slices"
+	"strconv"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/multitracer"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type config struct {
 	dsn   url.URL
 	query url.Values
+
+	maxTxLifetime time.Duration
+	onTxReaped    func(txID TxID, age time.Duration)
+	leakDetection bool
+	hooks         []Hooks
+	queryTracers  []pgx.QueryTracer
+
+	staleTXMode   staleTXMode
+	staleTXLogger *slog.Logger
+	idGenerator   func(ctx context.Context) TxID
+
+	failFastOnBusyTX bool
+
+	ping        bool
+	pingTimeout time.Duration
+
+	// errs collect every invalid option
+	errs []error
+}
+
+// sslModes is every sslmode supported by pgx
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// invalid will record an invalid option
+func (c *config) invalid(format string, args ...any) {
+	c.errs = append(c.errs, fmt.Errorf("%w: "+format, append([]any{ErrTxPoolInvalidConfig}, args...)...))
+}
+
+// validate will return every invalid option as one joined error
+func (c *config) validate() error {
+	return errors.Join(c.errs...)
 }
 
 func (c *config) SetQuery(key, value string) {
@@ -20,28 +59,71 @@ func (c *config) SetQuery(key, value string) {
 }
 
 // ParseToPGXConfig will parse dsn and query URL to pgxpool config
+// it will panic when dsn can not be parsed
 func (c *config) ParseToPGXConfig() *pgxpool.Config {
+	config, err := c.parseToPGXConfig()
+	if err != nil {
+		panic(err)
+	}
+	return config
+}
+
+// parseToPGXConfig will parse dsn and query URL to pgxpool config
+func (c *config) parseToPGXConfig() (*pgxpool.Config, error) {
 	c.dsn.Scheme = "postgres"
 	c.dsn.RawQuery = c.query.Encode()
 	config, err := pgxpool.ParseConfig(c.dsn.String())
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("%w: %w", ErrTxPoolInvalidConfig, err)
 	}
 
-	return config
+	c.installQueryTracers(config)
+
+	return config, nil
+}
+
+// installQueryTracers will install query tracers on pgxpool config
+// tracer that already set on the config is kept and called first
+func (c *config) installQueryTracers(pgxConfig *pgxpool.Config) {
+	tracers := c.queryTracers
+	if len(tracers) == 0 {
+		return
+	}
+	if tracer := pgxConfig.ConnConfig.Tracer; tracer != nil {
+		tracers = append([]pgx.QueryTracer{tracer}, tracers...)
+	}
+
+	if len(tracers) == 1 {
+		pgxConfig.ConnConfig.Tracer = tracers[0]
+		return
+	}
+	pgxConfig.ConnConfig.Tracer = multitracer.New(tracers...)
+}
+
+// rejectConnectionOptions will record options that build dsn as invalid
+// because connection is configured by dsn, pgxpool config or existing pool instead
+func (c *config) rejectConnectionOptions(constructor string) {
+	if c.dsn != (url.URL{}) || len(c.query) > 0 {
+		c.invalid("connection options like SetHost or WithMaxConns can not be used with %s", constructor)
+	}
 }
 
 // Option is a function that can be used to configure the pgxpool
 type Option func(*config)
 
 // SetHost will set postgres host and port
+// empty host or port use default of pgx, like libpq
 func SetHost(host, port string) Option {
 	return func(c *config) {
+		if p, err := strconv.Atoi(port); port != "" && (err != nil || p < 1 || p > 65535) {
+			c.invalid("port %q is not a valid port number", port)
+		}
 		c.dsn.Host = fmt.Sprintf("%s:%s", host, port)
 	}
 }
 
 // SetCredential will set postgres user and password
+// empty user use default of pgx, like libpq it is the current OS user
 func SetCredential(user, password string) Option {
 	return func(c *config) {
 		c.dsn.User = url.UserPassword(user, password)
@@ -49,6 +131,7 @@ func SetCredential(user, password string) Option {
 }
 
 // SetDatabase will set postgres database
+// empty database use default of pgx, like libpq it is the user name
 func SetDatabase(database string) Option {
 	return func(c *config) {
 		c.dsn.Path = database
@@ -56,8 +139,12 @@ func SetDatabase(database string) Option {
 }
 
 // WithSSLMode will set sslmode
+// ex: "disable", "require", "verify-full"
 func WithSSLMode(mode string) Option {
 	return func(c *config) {
+		if !slices.Contains(sslModes, mode) {
+			c.invalid("sslmode %q is not one of %v", mode, sslModes)
+		}
 		c.SetQuery("sslmode", mode)
 	}
 }
@@ -65,6 +152,9 @@ func WithSSLMode(mode string) Option {
 // WithMaxConns will set max connections
 func WithMaxConns(maxConns int) Option {
 	return func(c *config) {
+		if maxConns < 1 {
+			c.invalid("max conns must be greater than 0, got %d", maxConns)
+		}
 		c.SetQuery("pool_max_conns", fmt.Sprintf("%d", maxConns))
 	}
 }
@@ -73,6 +163,9 @@ func WithMaxConns(maxConns int) Option {
 // ex: "30s", "5m"
 func WithMaxIdleConns(maxIdleConns string) Option {
 	return func(c *config) {
+		if _, err := time.ParseDuration(maxIdleConns); err != nil {
+			c.invalid("max idle conns %q is not a valid duration", maxIdleConns)
+		}
 		c.SetQuery("pool_max_conn_idle_time", maxIdleConns)
 	}
 }
@@ -81,6 +174,130 @@ func WithMaxIdleConns(maxIdleConns string) Option {
 // ex: "30s", "5m"
 func WithMaxConnLifetime(maxConnLifetime string) Option {
 	return func(c *config) {
+		if _, err := time.ParseDuration(maxConnLifetime); err != nil {
+			c.invalid("max conn lifetime %q is not a valid duration", maxConnLifetime)
+		}
 		c.SetQuery("pool_max_conn_lifetime", maxConnLifetime)
 	}
 }
+
+// WithMaxTxLifetime will set max transaction lifetime
+// transaction that still in the pool longer than this will be rolled back by a background reaper
+// and CommitTX or RollbackTX will return ErrTxPoolLifetimeExceeded
+// scope that begun by propagation without transaction is removed the same way
+// the reaper is stopped when pool is closed
+func WithMaxTxLifetime(maxTxLifetime time.Duration) Option {
+	return func(c *config) {
+		if maxTxLifetime < 0 {
+			c.invalid("max transaction lifetime must not be negative, got %s", maxTxLifetime)
+		}
+		c.maxTxLifetime = maxTxLifetime
+	}
+}
+
+// WithOnTxReaped will set a function that called for each transaction rolled back by the reaper
+// by default reaped transaction is logged as a warning using slog default logger
+func WithOnTxReaped(onTxReaped func(txID TxID, age time.Duration)) Option {
+	return func(c *config) {
+		c.onTxReaped = onTxReaped
+	}
+}
+
+// WithLeakDetection will capture stack trace where transaction is begun
+// then it will be reported by LeakReport and VerifyTX
+// this option is intended for debugging, because capturing stack trace on every transaction is expensive
+func WithLeakDetection() Option {
+	return func(c *config) {
+		c.leakDetection = true
+	}
+}
+
+// WithHooks will add hooks that called on transaction lifecycle
+// it can be used multiple times, hooks are called in order they are added
+func WithHooks(hooks Hooks) Option {
+	return func(c *config) {
+		if hooks == nil {
+			c.invalid("hooks is nil")
+			return
+		}
+		c.hooks = append(c.hooks, hooks)
+	}
+}
+
+// WithQueryTracer will install a pgx query tracer on every connection in the pool
+// it can be used multiple times, tracers are called in order they are added
+// if tracer also implement Hooks then it is added as hooks too,
+// so a single tracer can follow both transaction lifecycle and statements
+func WithQueryTracer(tracer pgx.QueryTracer) Option {
+	return func(c *config) {
+		if tracer == nil {
+			c.invalid("query tracer is nil")
+			return
+		}
+		c.queryTracers = append(c.queryTracers, tracer)
+		if hooks, ok := tracer.(Hooks); ok {
+			c.hooks = append(c.hooks, hooks)
+		}
+	}
+}
+
+// WithPing will ping the database when pool is created using NewWithContext
+// so unreachable database is reported at startup instead of on the first query
+// timeout limit how long the ping can take, zero means it is only limited by the context
+func WithPing(timeout time.Duration) Option {
+	return func(c *config) {
+		if timeout < 0 {
+			c.invalid("ping timeout must not be negative, got %s", timeout)
+		}
+		c.ping = true
+		c.pingTimeout = timeout
+	}
+}
+
+// WithStrictMode will refuse to run query when context carry a transaction id
+// that is no longer in the pool (committed, rolled back or aborted) or belongs to another pool
+// query will return ErrTxPoolStaleTX or ErrTxPoolForeignTX, both can be matched with ErrTxPoolNotFound
+// by default such query run without transaction silently
+func WithStrictMode() Option {
+	return func(c *config) {
+		c.staleTXMode = staleTXReject
+	}
+}
+
+// WithLenientMode will log a warning using logger when context carry a transaction id
+// that is no longer in the pool or belongs to another pool, then run query without transaction
+// logger can be nil to use slog default logger
+func WithLenientMode(logger *slog.Logger) Option {
+	return func(c *config) {
+		if logger == nil {
+			logger = slog.Default()
+		}
+		c.staleTXMode = staleTXWarn
+		c.staleTXLogger = logger
+	}
+}
+
+// WithIDGenerator will set function that generate transaction id
+// ctx is the context that used to begin the transaction, so id can be derived from it
+// ex: trace id of the active span, or a sortable id like ULID
+// generated id must be unique among transactions in the pool,
+// duplicate or empty id is rejected with ErrTxPoolInvalidID
+// default generator use random UUID
+func WithIDGenerator(idGenerator func(ctx context.Context) TxID) Option {
+	return func(c *config) {
+		if idGenerator == nil {
+			c.invalid("id generator is nil")
+			return
+		}
+		c.idGenerator = idGenerator
+	}
+}
+
+// WithFailFastOnBusyTX will make statement fail with ErrTxPoolTxBusy
+// when its transaction is running another statement or has rows that are not closed
+// by default statement wait until transaction is free or its context is done
+func WithFailFastOnBusyTX() Option {
+	return func(c *config) {
+		c.failFastOnBusyTX = true
+	}
+}
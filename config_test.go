@@ -1,6 +1,27 @@
 package pgxtxpool
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/multitracer"
+)
+
+// recordQueryTracer is a pgx.QueryTracer that also implement Hooks
+type recordQueryTracer struct {
+	NoopHooks
+}
+
+func (*recordQueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return ctx
+}
+
+func (*recordQueryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+}
 
 func TestConfig(t *testing.T) {
 	t.Run("should not panic and return config", func(t *testing.T) {
@@ -21,6 +42,17 @@ func TestConfig(t *testing.T) {
 			WithMaxConns(10),
 			WithMaxIdleConns("30s"),
 			WithMaxConnLifetime("5m"),
+			WithMaxTxLifetime(time.Minute),
+			WithOnTxReaped(func(txID TxID, age time.Duration) {}),
+			WithLeakDetection(),
+			WithHooks(NoopHooks{}),
+			WithQueryTracer(&recordQueryTracer{}),
+			WithQueryLogger(slog.Default(), WithSlowQueryThreshold(time.Second)),
+			WithPing(time.Second),
+			WithStrictMode(),
+			WithLenientMode(nil),
+			WithIDGenerator(func(ctx context.Context) TxID { return "tx" }),
+			WithFailFastOnBusyTX(),
 		}
 
 		for _, opt := range options {
@@ -45,4 +77,95 @@ func TestConfig(t *testing.T) {
 		cfg.dsn.RawQuery = "sslmode=XXXX"
 		cfg.ParseToPGXConfig()
 	})
+
+	t.Run("should install query tracer and add it as hooks", func(t *testing.T) {
+		tracer := &recordQueryTracer{}
+		cfg := config{}
+		SetHost("localhost", "5432")(&cfg)
+		WithQueryTracer(tracer)(&cfg)
+
+		pgxCfg := cfg.ParseToPGXConfig()
+		if pgxCfg.ConnConfig.Tracer != tracer {
+			t.Log("query tracer is not installed")
+			t.FailNow()
+		}
+		if len(cfg.hooks) != 1 || cfg.hooks[0] != tracer {
+			t.Log("query tracer is not added as hooks")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should combine multiple query tracers", func(t *testing.T) {
+		cfg := config{}
+		SetHost("localhost", "5432")(&cfg)
+		WithQueryTracer(&recordQueryTracer{})(&cfg)
+		WithQueryTracer(&recordQueryTracer{})(&cfg)
+
+		pgxCfg := cfg.ParseToPGXConfig()
+		if _, ok := pgxCfg.ConnConfig.Tracer.(*multitracer.Tracer); !ok {
+			t.Logf("expected multitracer, got %T", pgxCfg.ConnConfig.Tracer)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should collect every invalid option", func(t *testing.T) {
+		cfg := config{}
+		options := []Option{
+			SetHost("localhost", "port"),
+			WithSSLMode("XXXX"),
+			WithMaxConns(0),
+			WithMaxIdleConns("30"),
+			WithMaxConnLifetime("forever"),
+			WithMaxTxLifetime(-time.Second),
+			WithHooks(nil),
+			WithQueryTracer(nil),
+			WithQueryLogger(nil),
+			WithPing(-time.Second),
+			WithIDGenerator(nil),
+		}
+		for _, opt := range options {
+			opt(&cfg)
+		}
+
+		err := cfg.validate()
+		if !errors.Is(err, ErrTxPoolInvalidConfig) {
+			t.Logf("expected error %v, got %v", ErrTxPoolInvalidConfig, err)
+			t.FailNow()
+		}
+
+		errs := err.(interface{ Unwrap() []error }).Unwrap()
+		if len(errs) != len(options) {
+			t.Logf("expected %d errors, got %d: %v", len(options), len(errs), err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should accept empty values that pgx default", func(t *testing.T) {
+		cfg := config{}
+		for _, opt := range []Option{SetHost("", ""), SetCredential("", "postgres"), SetDatabase("")} {
+			opt(&cfg)
+		}
+		if err := cfg.validate(); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		pgxCfg, err := cfg.parseToPGXConfig()
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if pgxCfg.ConnConfig.User == "" || pgxCfg.ConnConfig.Port != 5432 || pgxCfg.ConnConfig.Password != "postgres" {
+			t.Logf("expected default user and port, got %q and %d", pgxCfg.ConnConfig.User, pgxCfg.ConnConfig.Port)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should return error when parse to pgxpool config", func(t *testing.T) {
+		cfg := config{}
+		cfg.dsn.RawQuery = "sslmode=XXXX"
+		if _, err := cfg.parseToPGXConfig(); !errors.Is(err, ErrTxPoolInvalidConfig) {
+			t.Logf("expected error %v, got %v", ErrTxPoolInvalidConfig, err)
+			t.FailNow()
+		}
+	})
 }
@@ -4,7 +4,14 @@ package pgxtxpool
 type TxID string
 
 // TxContextID an indentifier for a transaction ID in context
+//
+// Deprecated: transaction id is carried using a key scoped per pool,
+// use Pool.TxIDFromContext and Pool.ContextWithTxID instead
 type TxContextID string
 
 // ContextTxKey a key for a transaction ID in context
+// pool only set transaction id using this key for code that still read it,
+// it is never read by the pool, so setting it does not join a transaction
+//
+// Deprecated: use Pool.TxIDFromContext or TxIDFromContext instead
 const ContextTxKey TxContextID = "TX_POOL_ID"
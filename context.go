@@ -0,0 +1,74 @@
+package pgxtxpool
+
+import "context"
+
+// txContextKey is a context key for transaction id that scoped per pool
+// so transaction id of a pool is never used by another pool
+// and it can not be forged outside this package
+type txContextKey struct {
+	pool *Pool
+}
+
+// currentTxKey is a context key for transaction id of the latest transaction
+// that begun in the context by any pool, it is used to correlate logs and traces
+type currentTxKey struct{}
+
+// currentOwnerTxKey is a context key for transaction id that own the connection of the latest transaction
+// savepoint and joined transaction carry id of the transaction they are begun from
+type currentOwnerTxKey struct{}
+
+// contextKey will return context key of the pool
+func (p *Pool) contextKey() txContextKey {
+	return txContextKey{pool: p}
+}
+
+// TxIDFromContext will return transaction id of the pool that carried in the context
+// transaction id of another pool is not returned
+func (p *Pool) TxIDFromContext(ctx context.Context) (TxID, bool) {
+	txID, ok := ctx.Value(p.contextKey()).(TxID)
+	return txID, ok && txID != ""
+}
+
+// HasTransaction will check whether context carry an active transaction of the pool
+// scope without transaction (PropagationSupports, PropagationNotSupported) is not an active transaction
+func (p *Pool) HasTransaction(ctx context.Context) bool {
+	_, ok := p.getTXFromContext(ctx)
+	return ok
+}
+
+// ContextWithTxID will return context that carry transaction id of the pool
+// use it to continue a transaction using a context that is not derived from context returned by BeginTX
+// ex: ctx := p.ContextWithTxID(context.Background(), txID)
+func (p *Pool) ContextWithTxID(ctx context.Context, txID TxID) context.Context {
+	ownerTxID := txID
+	if conn, ok := p.getTXConn(txID); ok && conn.root().txID != "" {
+		ownerTxID = conn.root().txID
+	}
+	ctx = context.WithValue(ctx, p.contextKey(), txID)
+	ctx = context.WithValue(ctx, currentTxKey{}, txID)
+	ctx = context.WithValue(ctx, currentOwnerTxKey{}, ownerTxID)
+	return context.WithValue(ctx, ContextTxKey, txID)
+}
+
+// contextWithoutTxID will return context that no longer carry transaction id of the pool
+func (p *Pool) contextWithoutTxID(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, p.contextKey(), nil)
+	ctx = context.WithValue(ctx, currentTxKey{}, nil)
+	ctx = context.WithValue(ctx, currentOwnerTxKey{}, nil)
+	return context.WithValue(ctx, ContextTxKey, nil)
+}
+
+// TxIDFromContext will return transaction id of the latest transaction that begun in the context by any pool
+// it is intended to correlate logs and traces, use Pool.TxIDFromContext to get transaction id of a specific pool
+func TxIDFromContext(ctx context.Context) (TxID, bool) {
+	txID, ok := ctx.Value(currentTxKey{}).(TxID)
+	return txID, ok && txID != ""
+}
+
+// OwnerTxIDFromContext will return transaction id that own the connection of the latest transaction
+// that begun in the context by any pool, savepoint and joined transaction return id of the transaction they are begun from
+// it is intended to correlate statements of nested transactions, ex: parent span of a statement
+func OwnerTxIDFromContext(ctx context.Context) (TxID, bool) {
+	txID, ok := ctx.Value(currentOwnerTxKey{}).(TxID)
+	return txID, ok && txID != ""
+}
@@ -0,0 +1,128 @@
+package pgxtxpool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestContextKey(t *testing.T) {
+	t.Run("should scope transaction id per pool", func(t *testing.T) {
+		poolA, _ := newFakePool()
+		poolB, _ := newFakePool()
+
+		ctxA, err := poolA.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		ctxAB, err := poolB.BeginTX(ctxA)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		// transaction of pool B must not replace transaction of pool A
+		txIDA, okA := poolA.TxIDFromContext(ctxAB)
+		txIDB, okB := poolB.TxIDFromContext(ctxAB)
+		if !okA || !okB || txIDA == txIDB || txIDA != txIDOf(poolA, ctxA) {
+			t.Logf("unexpected transaction ids %s and %s", txIDA, txIDB)
+			t.FailNow()
+		}
+		if !poolA.HasTransaction(ctxAB) || !poolB.HasTransaction(ctxAB) {
+			t.Log("both pools should have an active transaction")
+			t.FailNow()
+		}
+		if poolB.HasTransaction(ctxA) {
+			t.Log("pool B should not have transaction in context of pool A")
+			t.FailNow()
+		}
+
+		// latest transaction is used for correlation
+		if current, _ := TxIDFromContext(ctxAB); current != txIDB {
+			t.Logf("expected current transaction id %s, got %s", txIDB, current)
+			t.FailNow()
+		}
+
+		poolB.CommitTX(ctxAB)
+		poolA.CommitTX(ctxA)
+	})
+
+	t.Run("should carry transaction that own the connection", func(t *testing.T) {
+		p, _ := newFakePool()
+
+		txCTX, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		savepointCTX, err := p.BeginTX(txCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		joinedCTX, err := p.BeginTXWithPropagation(savepointCTX, PropagationRequired, pgx.TxOptions{})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		for _, ctx := range []context.Context{txCTX, savepointCTX, joinedCTX} {
+			if owner, ok := OwnerTxIDFromContext(ctx); !ok || owner != txIDOf(p, txCTX) {
+				t.Logf("expected owner transaction id %s, got %s", txIDOf(p, txCTX), owner)
+				t.FailNow()
+			}
+		}
+		if current, _ := TxIDFromContext(joinedCTX); current != txIDOf(p, joinedCTX) {
+			t.Logf("expected current transaction id %s, got %s", txIDOf(p, joinedCTX), current)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should not join transaction using forged ContextTxKey", func(t *testing.T) {
+		p, _ := newFakePool()
+		ctx, _ := newFakeTXContext(p)
+
+		forged := context.WithValue(context.Background(), ContextTxKey, txIDOf(p, ctx))
+		if p.HasTransaction(forged) {
+			t.Log("forged context should not carry transaction")
+			t.FailNow()
+		}
+		if err := p.CommitTX(forged); err != ErrTxPoolIDNotFound {
+			t.Logf("expected error %v, got %v", ErrTxPoolIDNotFound, err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should keep ContextTxKey readable for compatibility", func(t *testing.T) {
+		p, _ := newFakePool()
+		ctx, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if ctx.Value(ContextTxKey) != txIDOf(p, ctx) {
+			t.Log("ContextTxKey should carry transaction id")
+			t.FailNow()
+		}
+		p.RollbackTX(ctx)
+	})
+
+	t.Run("should continue transaction using ContextWithTxID", func(t *testing.T) {
+		p, _ := newFakePool()
+		ctx, tx := newFakeTXContext(p)
+
+		resumed := p.ContextWithTxID(context.Background(), txIDOf(p, ctx))
+		p.Exec(resumed, "SELECT 1")
+		if !tx.called("Exec") {
+			t.Log("query should use the transaction")
+			t.FailNow()
+		}
+
+		if _, ok := p.TxIDFromContext(p.contextWithoutTxID(resumed)); ok {
+			t.Log("context without transaction id should not carry transaction")
+			t.FailNow()
+		}
+	})
+}
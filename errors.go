@@ -6,7 +6,7 @@ import "fmt"
 // this is a parent error (L1)
 var ErrTxPool = fmt.Errorf("pgx tx pool error")
 
-// ErrTxPoolIDNotFound will indicate that current context does not have transaction id with key ContextTxKey
+// ErrTxPoolIDNotFound will indicate that current context does not have transaction id of the pool
 // this is a child error (L2)
 var ErrTxPoolIDNotFound = fmt.Errorf("%w: transaction id not found in context", ErrTxPool)
 
@@ -17,3 +17,88 @@ var ErrTxPoolNotFound = fmt.Errorf("%w: transaction id not found in pool", ErrTx
 // ErrTxPoolTrxStillExistsInPool will indicate that transaction with id that found in context still exists in pool
 // this is a child error (L2)
 var ErrTxPoolTrxStillExistsInPool = fmt.Errorf("%w: transaction still exists in pool", ErrTxPool)
+
+// ErrTxPoolOptionsMismatch will indicate that nested transaction is requested with options
+// that different from options of its parent transaction
+// this is a child error (L2)
+var ErrTxPoolOptionsMismatch = fmt.Errorf("%w: nested transaction options mismatch with parent transaction", ErrTxPool)
+
+// ErrTxPoolRetryExhausted will indicate that transaction still failed with retryable error
+// after maximum attempts is reached
+// this is a child error (L2)
+var ErrTxPoolRetryExhausted = fmt.Errorf("%w: transaction retry exhausted", ErrTxPool)
+
+// ErrTxPoolTransactionRequired will indicate that PropagationMandatory is used
+// but current context does not carry an active transaction
+// this is a child error (L2)
+var ErrTxPoolTransactionRequired = fmt.Errorf("%w: active transaction required but not found", ErrTxPool)
+
+// ErrTxPoolTransactionExists will indicate that PropagationNever is used
+// but current context carry an active transaction
+// this is a child error (L2)
+var ErrTxPoolTransactionExists = fmt.Errorf("%w: active transaction exists but not allowed", ErrTxPool)
+
+// ErrTxPoolInvalidPropagation will indicate that propagation is unknown
+// this is a child error (L2)
+var ErrTxPoolInvalidPropagation = fmt.Errorf("%w: invalid transaction propagation", ErrTxPool)
+
+// ErrTxPoolRollbackOnly will indicate that transaction is rolled back on commit
+// because one of its participant has been rolled back
+// this is a child error (L2)
+var ErrTxPoolRollbackOnly = fmt.Errorf("%w: transaction marked as rollback only", ErrTxPool)
+
+// ErrTxPoolAbortedByContext will indicate that transaction has been rolled back and removed from pool
+// because context that used to begin it is cancelled or its deadline passes
+// this is a child error (L2)
+var ErrTxPoolAbortedByContext = fmt.Errorf("%w: transaction aborted by context", ErrTxPool)
+
+// ErrTxPoolLifetimeExceeded will indicate that transaction has been rolled back and removed from pool
+// by the reaper because it is in the pool longer than max transaction lifetime
+// this is a child error (L2)
+var ErrTxPoolLifetimeExceeded = fmt.Errorf("%w: transaction lifetime exceeded", ErrTxPool)
+
+// ErrTxPoolInvalidConfig will indicate that an option is invalid
+// NewWithContext join every invalid option into one error
+// this is a child error (L2)
+var ErrTxPoolInvalidConfig = fmt.Errorf("%w: invalid configuration", ErrTxPool)
+
+// ErrTxPoolPingFailed will indicate that database can not be reached when pool is created using WithPing
+// this is a child error (L2)
+var ErrTxPoolPingFailed = fmt.Errorf("%w: failed to ping database", ErrTxPool)
+
+// ErrTxPoolStaleTX will indicate that context carry a transaction id that is no longer in the pool
+// because it has been committed, rolled back or aborted, it is returned in strict mode
+// this is a grandchild error (L3) of ErrTxPoolNotFound
+var ErrTxPoolStaleTX = fmt.Errorf("%w: transaction is no longer active", ErrTxPoolNotFound)
+
+// ErrTxPoolForeignTX will indicate that context carry a transaction id that belongs to another pool
+// it is returned in strict mode
+// this is a grandchild error (L3) of ErrTxPoolNotFound
+var ErrTxPoolForeignTX = fmt.Errorf("%w: transaction belongs to another pool", ErrTxPoolNotFound)
+
+// ErrTxPoolInvalidID will indicate that generated transaction id is empty
+// or already registered in the pool by another transaction
+// this is a child error (L2)
+var ErrTxPoolInvalidID = fmt.Errorf("%w: generated transaction id is empty or already registered", ErrTxPool)
+
+// ErrTxPoolTxBusy will indicate that transaction is running another statement
+// or has rows that are not closed, statement return it when fail fast is enabled,
+// CommitTX and RollbackTX return it when statement is still running after their context is done
+// or fail fast is enabled, then transaction is rolled back after the statement return
+// this is a child error (L2)
+var ErrTxPoolTxBusy = fmt.Errorf("%w: transaction is busy with another statement", ErrTxPool)
+
+// ErrTxPoolResultClosed will indicate that rows, row or batch results are closed
+// because their transaction is committed, rolled back or aborted before they are closed
+// this is a child error (L2)
+var ErrTxPoolResultClosed = fmt.Errorf("%w: result closed because its transaction is ended", ErrTxPool)
+
+// ErrTxPoolClosed will indicate that pool is shutting down or closed
+// so new transaction can not be begun
+// this is a child error (L2)
+var ErrTxPoolClosed = fmt.Errorf("%w: pool is closed", ErrTxPool)
+
+// ErrTxPoolShutdown will indicate that transaction has been rolled back and removed from pool
+// because it is still open when Shutdown deadline passes
+// this is a child error (L2)
+var ErrTxPoolShutdown = fmt.Errorf("%w: transaction rolled back by shutdown", ErrTxPool)
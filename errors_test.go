@@ -12,6 +12,23 @@ func TestErrors(t *testing.T) {
 		ErrTxPoolIDNotFound,
 		ErrTxPoolNotFound,
 		ErrTxPoolTrxStillExistsInPool,
+		ErrTxPoolOptionsMismatch,
+		ErrTxPoolRetryExhausted,
+		ErrTxPoolTransactionRequired,
+		ErrTxPoolTransactionExists,
+		ErrTxPoolInvalidPropagation,
+		ErrTxPoolRollbackOnly,
+		ErrTxPoolAbortedByContext,
+		ErrTxPoolLifetimeExceeded,
+		ErrTxPoolInvalidConfig,
+		ErrTxPoolPingFailed,
+		ErrTxPoolStaleTX,
+		ErrTxPoolForeignTX,
+		ErrTxPoolInvalidID,
+		ErrTxPoolTxBusy,
+		ErrTxPoolResultClosed,
+		ErrTxPoolClosed,
+		ErrTxPoolShutdown,
 	}
 	for _, err := range arrErr {
 		testName := fmt.Sprintf("TEST: %s", err.Error())
@@ -0,0 +1,186 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Hooks is a set of callbacks that called on transaction lifecycle
+// only transaction that begun from pgxpool trigger hooks,
+// savepoint, joined and scope without transaction do not trigger hooks
+// elapsed is time taken to begin the transaction on OnBegin
+// and time since transaction is begun on other callbacks
+type Hooks interface {
+	// OnBegin is called after transaction is begun and registered in the pool
+	OnBegin(ctx context.Context, txID TxID, elapsed time.Duration)
+
+	// BeforeCommit is called before transaction is committed
+	// when it return an error, transaction will be rolled back instead
+	// and CommitTX will return that error
+	BeforeCommit(ctx context.Context, txID TxID, elapsed time.Duration) error
+
+	// AfterCommit is called after transaction is committed successfully
+	AfterCommit(ctx context.Context, txID TxID, elapsed time.Duration)
+
+	// AfterRollback is called after transaction is rolled back
+	// including rollback by BeforeCommit, context watcher and reaper
+	AfterRollback(ctx context.Context, txID TxID, elapsed time.Duration)
+
+	// OnError is called when begin, commit or rollback failed
+	// txID is empty when begin failed
+	OnError(ctx context.Context, txID TxID, elapsed time.Duration, err error)
+}
+
+// TxOptionsHooks is an optional interface that Hooks can implement
+// to receive options of a transaction, it is called right after OnBegin
+// ex: to report isolation level of the transaction
+type TxOptionsHooks interface {
+	OnTxOptions(ctx context.Context, txID TxID, txOptions pgx.TxOptions)
+}
+
+// TxEndHooks is an optional interface that Hooks can implement
+// to receive a snapshot of a transaction when it is ended,
+// it is called right after AfterCommit, AfterRollback or OnError of commit and rollback
+// ex: to observe duration and number of statements of the transaction
+type TxEndHooks interface {
+	OnTxEnd(ctx context.Context, info TxInfo)
+}
+
+// NoopHooks is a Hooks that do nothing
+// embed it to implement only callbacks that needed
+type NoopHooks struct{}
+
+// OnBegin do nothing
+func (NoopHooks) OnBegin(ctx context.Context, txID TxID, elapsed time.Duration) {}
+
+// BeforeCommit do nothing
+func (NoopHooks) BeforeCommit(ctx context.Context, txID TxID, elapsed time.Duration) error {
+	return nil
+}
+
+// AfterCommit do nothing
+func (NoopHooks) AfterCommit(ctx context.Context, txID TxID, elapsed time.Duration) {}
+
+// AfterRollback do nothing
+func (NoopHooks) AfterRollback(ctx context.Context, txID TxID, elapsed time.Duration) {}
+
+// OnError do nothing
+func (NoopHooks) OnError(ctx context.Context, txID TxID, elapsed time.Duration, err error) {}
+
+// multiHooks will call every hooks in order they are added
+type multiHooks []Hooks
+
+func (m multiHooks) OnBegin(ctx context.Context, txID TxID, elapsed time.Duration) {
+	for _, h := range m {
+		h.OnBegin(ctx, txID, elapsed)
+	}
+}
+
+// OnTxOptions will call every hooks that implement TxOptionsHooks
+func (m multiHooks) OnTxOptions(ctx context.Context, txID TxID, txOptions pgx.TxOptions) {
+	for _, h := range m {
+		if h, ok := h.(TxOptionsHooks); ok {
+			h.OnTxOptions(ctx, txID, txOptions)
+		}
+	}
+}
+
+// onTxEnd will call every hooks that implement TxEndHooks
+// snapshot is only created when there is hooks to receive it
+func (m multiHooks) onTxEnd(ctx context.Context, txID TxID, conn *txConn) {
+	var info *TxInfo
+	for _, h := range m {
+		if h, ok := h.(TxEndHooks); ok {
+			if info == nil {
+				snapshot := newTxInfo(txID, conn)
+				info = &snapshot
+			}
+			h.OnTxEnd(ctx, *info)
+		}
+	}
+}
+
+// BeforeCommit will stop at the first hooks that return an error
+func (m multiHooks) BeforeCommit(ctx context.Context, txID TxID, elapsed time.Duration) error {
+	for _, h := range m {
+		if err := h.BeforeCommit(ctx, txID, elapsed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiHooks) AfterCommit(ctx context.Context, txID TxID, elapsed time.Duration) {
+	for _, h := range m {
+		h.AfterCommit(ctx, txID, elapsed)
+	}
+}
+
+func (m multiHooks) AfterRollback(ctx context.Context, txID TxID, elapsed time.Duration) {
+	for _, h := range m {
+		h.AfterRollback(ctx, txID, elapsed)
+	}
+}
+
+func (m multiHooks) OnError(ctx context.Context, txID TxID, elapsed time.Duration, err error) {
+	for _, h := range m {
+		h.OnError(ctx, txID, elapsed, err)
+	}
+}
+
+// hooksFor will return hooks that should be called for a transaction
+// only transaction that begun from pgxpool trigger hooks
+func (p *Pool) hooksFor(conn *txConn) multiHooks {
+	if conn.kind != TxKindTransaction {
+		return nil
+	}
+	return p.hooks
+}
+
+// commitTX will commit a transaction or release a savepoint
+// and call hooks around it
+// its savepoints and joined transactions are removed from the pool,
+// because they end with it
+func (p *Pool) commitTX(ctx context.Context, txID TxID, conn *txConn) error {
+	p.dropDependentTX(conn, nil)
+	hooks := p.hooksFor(conn)
+	if err := hooks.BeforeCommit(ctx, txID, time.Since(conn.startedAt)); err != nil {
+		if errRollback := p.rollbackTX(ctx, txID, conn); errRollback != nil {
+			return errors.Join(err, errRollback)
+		}
+		return err
+	}
+
+	// transaction that failed to commit is rolled back by postgres
+	if err := conn.tx.Commit(ctx); err != nil {
+		p.stats.recordRollback(conn)
+		hooks.OnError(ctx, txID, time.Since(conn.startedAt), err)
+		hooks.onTxEnd(ctx, txID, conn)
+		return err
+	}
+	p.stats.recordCommit(conn)
+	hooks.AfterCommit(ctx, txID, time.Since(conn.startedAt))
+	hooks.onTxEnd(ctx, txID, conn)
+	p.runAfterCommit(ctx, conn)
+	return nil
+}
+
+// rollbackTX will rollback a transaction or rollback to a savepoint
+// and call hooks after it
+// its savepoints and joined transactions are removed from the pool like commitTX
+func (p *Pool) rollbackTX(ctx context.Context, txID TxID, conn *txConn) error {
+	p.dropDependentTX(conn, nil)
+	hooks := p.hooksFor(conn)
+	p.stats.recordRollback(conn)
+	if err := conn.tx.Rollback(ctx); err != nil {
+		hooks.OnError(ctx, txID, time.Since(conn.startedAt), err)
+		hooks.onTxEnd(ctx, txID, conn)
+		return err
+	}
+	hooks.AfterRollback(ctx, txID, time.Since(conn.startedAt))
+	hooks.onTxEnd(ctx, txID, conn)
+	return nil
+}
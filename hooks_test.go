@@ -0,0 +1,237 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// recordHooks is a Hooks that record every callback that has been called
+type recordHooks struct {
+	NoopHooks
+	mx              sync.Mutex
+	calls           []string
+	beforeCommitErr error
+}
+
+func (h *recordHooks) record(call string, ctx context.Context, txID TxID) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if ctxTxID, _ := TxIDFromContext(ctx); ctxTxID != txID {
+		call += "(missing tx id)"
+	}
+	h.calls = append(h.calls, call)
+}
+
+func (h *recordHooks) recorded() []string {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	return append([]string(nil), h.calls...)
+}
+
+func (h *recordHooks) OnBegin(ctx context.Context, txID TxID, elapsed time.Duration) {
+	h.record("OnBegin", ctx, txID)
+}
+
+func (h *recordHooks) BeforeCommit(ctx context.Context, txID TxID, elapsed time.Duration) error {
+	h.record("BeforeCommit", ctx, txID)
+	return h.beforeCommitErr
+}
+
+func (h *recordHooks) AfterCommit(ctx context.Context, txID TxID, elapsed time.Duration) {
+	h.record("AfterCommit", ctx, txID)
+}
+
+func (h *recordHooks) AfterRollback(ctx context.Context, txID TxID, elapsed time.Duration) {
+	h.record("AfterRollback", ctx, txID)
+}
+
+func (h *recordHooks) OnError(ctx context.Context, txID TxID, elapsed time.Duration, err error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.calls = append(h.calls, "OnError")
+}
+
+// optionsHooks is a Hooks that record options received by OnTxOptions
+type optionsHooks struct {
+	NoopHooks
+	options []pgx.TxOptions
+}
+
+func (h *optionsHooks) OnTxOptions(ctx context.Context, txID TxID, txOptions pgx.TxOptions) {
+	h.options = append(h.options, txOptions)
+}
+
+// endHooks is a Hooks that record snapshot received by OnTxEnd
+type endHooks struct {
+	NoopHooks
+	infos []TxInfo
+}
+
+func (h *endHooks) OnTxEnd(ctx context.Context, info TxInfo) {
+	h.infos = append(h.infos, info)
+}
+
+func TestHooks(t *testing.T) {
+	t.Run("should call on tx end with snapshot of the transaction", func(t *testing.T) {
+		p, _ := newFakePool()
+		hooks := &endHooks{}
+		p.hooks = multiHooks{hooks}
+
+		p.WithTransaction(context.Background(), func(ctx context.Context) error {
+			p.Exec(ctx, "SELECT 1")
+			p.Exec(ctx, "SELECT 2")
+			return nil
+		})
+		p.WithTransaction(context.Background(), func(ctx context.Context) error {
+			return errors.New("something went wrong")
+		})
+
+		if len(hooks.infos) != 2 {
+			t.Logf("expected 2 snapshots, got %d", len(hooks.infos))
+			t.FailNow()
+		}
+		if hooks.infos[0].QueryCount != 2 || hooks.infos[1].QueryCount != 0 {
+			t.Logf("unexpected query count %d and %d", hooks.infos[0].QueryCount, hooks.infos[1].QueryCount)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should call on tx options for hooks that implement it", func(t *testing.T) {
+		p, _ := newFakePool()
+		hooks := &optionsHooks{}
+		p.hooks = multiHooks{&recordHooks{}, hooks}
+
+		txOptions := pgx.TxOptions{IsoLevel: pgx.Serializable}
+		ctx, err := p.BeginTXWithOptions(context.Background(), txOptions)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		// savepoint should not trigger hooks
+		if _, err := p.BeginTXWithOptions(ctx, txOptions); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if !slices.Equal(hooks.options, []pgx.TxOptions{txOptions}) {
+			t.Logf("expected options %v, got %v", txOptions, hooks.options)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should call hooks on commit", func(t *testing.T) {
+		p, _ := newFakePool()
+		hooks := &recordHooks{}
+		p.hooks = multiHooks{hooks}
+
+		err := p.WithTransaction(context.Background(), func(ctx context.Context) error {
+			// savepoint should not trigger hooks
+			return p.WithTransaction(ctx, func(ctx context.Context) error { return nil })
+		})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		expCalls := []string{"OnBegin", "BeforeCommit", "AfterCommit"}
+		if calls := hooks.recorded(); !slices.Equal(calls, expCalls) {
+			t.Logf("expected calls %v, got %v", expCalls, calls)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should call hooks on rollback", func(t *testing.T) {
+		p, _ := newFakePool()
+		hooks := &recordHooks{}
+		p.hooks = multiHooks{hooks}
+
+		p.WithTransaction(context.Background(), func(ctx context.Context) error {
+			return errors.New("something went wrong")
+		})
+
+		expCalls := []string{"OnBegin", "AfterRollback"}
+		if calls := hooks.recorded(); !slices.Equal(calls, expCalls) {
+			t.Logf("expected calls %v, got %v", expCalls, calls)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should rollback when before commit return error", func(t *testing.T) {
+		p, begun := newFakePool()
+		expErr := errors.New("cache unavailable")
+		first := &recordHooks{beforeCommitErr: expErr}
+		second := &recordHooks{}
+		p.hooks = multiHooks{first, second}
+
+		err := p.WithTransaction(context.Background(), func(ctx context.Context) error { return nil })
+		if !errors.Is(err, expErr) {
+			t.Logf("expected error %v, got %v", expErr, err)
+			t.FailNow()
+		}
+
+		tx := (*begun)[0]
+		if tx.called("Commit") || !tx.called("Rollback") {
+			t.Log("transaction should be rolled back instead of committed")
+			t.FailNow()
+		}
+
+		expCalls := []string{"OnBegin", "BeforeCommit", "AfterRollback"}
+		if calls := first.recorded(); !slices.Equal(calls, expCalls) {
+			t.Logf("expected calls %v, got %v", expCalls, calls)
+			t.FailNow()
+		}
+
+		// second hooks BeforeCommit is not called after first hooks return error
+		expCalls = []string{"OnBegin", "AfterRollback"}
+		if calls := second.recorded(); !slices.Equal(calls, expCalls) {
+			t.Logf("expected calls %v, got %v", expCalls, calls)
+			t.FailNow()
+		}
+
+		if stats := p.TxStats(); stats.RolledBack != 1 || stats.Committed != 0 {
+			t.Logf("unexpected stats %+v", stats)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should call on error when begin failed", func(t *testing.T) {
+		p, _ := newFakePool()
+		hooks := &recordHooks{}
+		p.hooks = multiHooks{hooks}
+		expErr := errors.New("connection refused")
+		p.beginTx = func(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+			return nil, expErr
+		}
+
+		if _, err := p.BeginTX(context.Background()); !errors.Is(err, expErr) {
+			t.Logf("expected error %v, got %v", expErr, err)
+			t.FailNow()
+		}
+
+		expCalls := []string{"OnError"}
+		if calls := hooks.recorded(); !slices.Equal(calls, expCalls) {
+			t.Logf("expected calls %v, got %v", expCalls, calls)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should call after rollback when aborted by context", func(t *testing.T) {
+		p, _ := newFakePool()
+		hooks := &recordHooks{}
+		p.hooks = multiHooks{hooks}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		p.BeginTX(ctx)
+		cancel()
+
+		expCalls := []string{"OnBegin", "AfterRollback"}
+		waitUntil(t, func() bool { return slices.Equal(hooks.recorded(), expCalls) })
+	})
+}
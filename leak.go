@@ -0,0 +1,85 @@
+package pgxtxpool
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxOriginDepth is maximum number of stack frames captured as transaction origin
+const maxOriginDepth = 32
+
+// TxLeak is a transaction that still open in the pool
+// Origin is stack trace where the transaction is begun,
+// it is only captured when pool is created using WithLeakDetection
+type TxLeak struct {
+	TxID      TxID
+	StartedAt time.Time
+	Age       time.Duration
+	Origin    string
+}
+
+// TxLeakError is returned by VerifyTX when transaction still exists in the pool
+// it can be matched with ErrTxPoolTrxStillExistsInPool using errors.Is
+type TxLeakError struct {
+	TxLeak
+}
+
+// Error will return error message along with age and origin of the transaction
+func (e *TxLeakError) Error() string {
+	msg := fmt.Sprintf("%s: tx_id=%s age=%s", ErrTxPoolTrxStillExistsInPool, e.TxID, e.Age)
+	if e.Origin != "" {
+		msg = fmt.Sprintf("%s origin:\n%s", msg, e.Origin)
+	}
+	return msg
+}
+
+// Unwrap will return ErrTxPoolTrxStillExistsInPool
+func (e *TxLeakError) Unwrap() error {
+	return ErrTxPoolTrxStillExistsInPool
+}
+
+// LeakReport will return every transaction that still open in the pool
+// sorted from the oldest one
+func (p *Pool) LeakReport() []TxLeak {
+	var leaks []TxLeak
+	now := time.Now()
+	p.txpool.Range(func(key, value any) bool {
+		leaks = append(leaks, newTxLeak(key.(TxID), value.(*txConn), now))
+		return true
+	})
+	sort.Slice(leaks, func(i, j int) bool {
+		return leaks[i].StartedAt.Before(leaks[j].StartedAt)
+	})
+	return leaks
+}
+
+// newTxLeak will create a leak report of a registered transaction
+func newTxLeak(txID TxID, conn *txConn, now time.Time) TxLeak {
+	return TxLeak{
+		TxID:      txID,
+		StartedAt: conn.startedAt,
+		Age:       now.Sub(conn.startedAt),
+		Origin:    conn.origin,
+	}
+}
+
+// captureOrigin will capture stack trace of the caller
+// skip is number of stack frames to skip, 0 is the caller of captureOrigin
+func captureOrigin(skip int) string {
+	pcs := make([]uintptr, maxOriginDepth)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var origin strings.Builder
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&origin, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return origin.String()
+}
@@ -0,0 +1,100 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leakTX will begin a transaction and never commit or rollback it
+func leakTX(p *Pool) context.Context {
+	ctx, _ := p.BeginTX(context.Background())
+	return ctx
+}
+
+func TestLeakDetection(t *testing.T) {
+	t.Run("should return leak error with origin from VerifyTX", func(t *testing.T) {
+		p, _ := newFakePool()
+		p.leakDetection = true
+
+		ctx := leakTX(p)
+
+		err := p.VerifyTX(ctx)
+		if !errors.Is(err, ErrTxPoolTrxStillExistsInPool) {
+			t.Logf("expected error %v, got %v", ErrTxPoolTrxStillExistsInPool, err)
+			t.FailNow()
+		}
+
+		var leakErr *TxLeakError
+		if !errors.As(err, &leakErr) {
+			t.Logf("expected error type %T, got %T", leakErr, err)
+			t.FailNow()
+		}
+
+		if leakErr.TxID != txIDOf(p, ctx) || leakErr.StartedAt.IsZero() {
+			t.Logf("unexpected leak %+v", leakErr.TxLeak)
+			t.FailNow()
+		}
+
+		if !strings.Contains(leakErr.Origin, "pgx-txpool.leakTX") || strings.Contains(leakErr.Origin, "captureOrigin") {
+			t.Logf("origin should start from caller of BeginTX, got:\n%s", leakErr.Origin)
+			t.FailNow()
+		}
+
+		if !strings.Contains(err.Error(), "pgx-txpool.leakTX") {
+			t.Logf("error message should contain origin, got: %s", err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should not capture origin when leak detection disabled", func(t *testing.T) {
+		p, _ := newFakePool()
+
+		ctx := leakTX(p)
+
+		var leakErr *TxLeakError
+		if !errors.As(p.VerifyTX(ctx), &leakErr) {
+			t.Log("expected leak error")
+			t.FailNow()
+		}
+
+		if leakErr.Origin != "" {
+			t.Logf("expected empty origin, got:\n%s", leakErr.Origin)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should report every open transaction from the oldest", func(t *testing.T) {
+		p, _ := newFakePool()
+		p.leakDetection = true
+
+		first := leakTX(p)
+		time.Sleep(time.Millisecond)
+		second := leakTX(p)
+		committed := leakTX(p)
+		if err := p.CommitTX(committed); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		leaks := p.LeakReport()
+		if len(leaks) != 2 {
+			t.Logf("expected 2 leaks, got %d", len(leaks))
+			t.FailNow()
+		}
+
+		if leaks[0].TxID != txIDOf(p, first) || leaks[1].TxID != txIDOf(p, second) {
+			t.Logf("leaks should be sorted from the oldest, got %v and %v", leaks[0].TxID, leaks[1].TxID)
+			t.FailNow()
+		}
+
+		for _, leak := range leaks {
+			if leak.Origin == "" || leak.Age < 0 {
+				t.Logf("unexpected leak %+v", leak)
+				t.FailNow()
+			}
+		}
+	})
+}
@@ -0,0 +1,472 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// txLock serialize statements of a transaction
+// pgx.Tx can only run one statement at a time,
+// but context that carry the transaction can be shared by many goroutines
+// savepoint and joined transaction share the lock of transaction that own the connection
+// nil txLock is always unlocked, it is used by scope without transaction
+type txLock struct {
+	mx   sync.Mutex
+	held bool
+
+	// closeResult close rows, row or batch results that hold the lock,
+	// it is nil when lock is held by a running statement
+	closeResult func()
+
+	// onRelease end transactions that are ended while a statement is running,
+	// they are run by the holder before the lock is released
+	onRelease []func()
+
+	// changed is closed and replaced every time lock is released or held by a result
+	changed chan struct{}
+}
+
+// errTXBusy is returned by lock when fail fast is used and lock is held
+var errTXBusy = errors.New("is running another statement or has rows that are not closed")
+
+// newTXLock will create an unlocked txLock
+func newTXLock() *txLock {
+	return &txLock{changed: make(chan struct{})}
+}
+
+// lock will wait until lock is acquired or context is done
+// failFast return errTXBusy instead of waiting
+// lock is never acquired by goroutine that still hold it through rows that are not closed,
+// so its statement wait until its context is done
+func (l *txLock) lock(ctx context.Context, failFast bool) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		l.mx.Lock()
+		if !l.held {
+			l.held, l.closeResult = true, nil
+			l.mx.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.mx.Unlock()
+
+		if failFast {
+			return errTXBusy
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// end will run end while holding the lock, it is used to commit or rollback a transaction
+// result that hold the lock is closed, then running statement is waited until ctx is done when wait is true,
+// otherwise end is deferred and run by the goroutine that run the statement after it return,
+// so end never run concurrently with a statement
+// it return false when end is deferred, then end is called with deferred true
+func (l *txLock) end(ctx context.Context, wait bool, end func(deferred bool)) bool {
+	if l == nil {
+		end(false)
+		return true
+	}
+	closed := false
+	for {
+		l.mx.Lock()
+		if !l.held {
+			l.held, l.closeResult = true, nil
+			l.mx.Unlock()
+			end(false)
+			l.unlock()
+			return true
+		}
+
+		// result is closed right away when no call is reading it,
+		// otherwise it is closed when the call return
+		if closeResult := l.closeResult; closeResult != nil && !closed {
+			l.mx.Unlock()
+			closeResult()
+			closed = true
+			continue
+		}
+
+		if !wait || ctx.Err() != nil {
+			l.onRelease = append(l.onRelease, func() { end(true) })
+			l.mx.Unlock()
+			return false
+		}
+		changed := l.changed
+		l.mx.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+		}
+		closed = false
+	}
+}
+
+// holdResult will mark the lock as held by a result until it is closed
+// closeResult is used to close it when transaction is ended
+// result of a statement that is running when its transaction is ended is closed right away
+func (l *txLock) holdResult(closeResult func()) {
+	l.mx.Lock()
+	l.closeResult = closeResult
+	l.notify()
+	ended := len(l.onRelease) > 0
+	l.mx.Unlock()
+
+	if ended {
+		closeResult()
+	}
+}
+
+// unlock will release the lock
+// transaction that is ended while the lock is held is ended first
+func (l *txLock) unlock() {
+	if l == nil {
+		return
+	}
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.closeResult = nil
+	for len(l.onRelease) > 0 {
+		onRelease := l.onRelease
+		l.onRelease = nil
+		l.mx.Unlock()
+		for _, end := range onRelease {
+			end()
+		}
+		l.mx.Lock()
+	}
+	l.held = false
+	l.notify()
+}
+
+// notify will wake every goroutine that wait for the lock, l.mx must be held
+func (l *txLock) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// lockTX will acquire lock of a transaction to run a statement
+// it wait for statement that still running, unless WithFailFastOnBusyTX is used
+// then it return ErrTxPoolTxBusy immediately
+func (p *Pool) lockTX(ctx context.Context, txID TxID, conn *txConn) error {
+	err := conn.lock.lock(ctx, p.failFastOnBusyTX)
+	if errors.Is(err, errTXBusy) {
+		return fmt.Errorf("%w: transaction %s %w", ErrTxPoolTxBusy, txID, err)
+	}
+	return err
+}
+
+// endTX will run end while holding lock of a transaction that is taken from the pool
+// result that hold the lock is closed and running statement is waited until ctx is done,
+// when ctx is done first or WithFailFastOnBusyTX is used,
+// transaction is rolled back after the statement return and ErrTxPoolTxBusy is returned
+func (p *Pool) endTX(ctx context.Context, txID TxID, conn *txConn, end func() error) error {
+	var err error
+	ended := conn.lock.end(ctx, !p.failFastOnBusyTX, func(deferred bool) {
+		if deferred {
+			p.rollbackTX(context.WithoutCancel(ctx), txID, conn)
+			return
+		}
+		err = end()
+	})
+	if ended {
+		return err
+	}
+	err = fmt.Errorf("%w: transaction %s is rolled back after its running statement return", ErrTxPoolTxBusy, txID)
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", err, ctx.Err())
+	}
+	return err
+}
+
+// txGuard release lock of a transaction only once
+// so result that is closed more than once does not release lock held by another statement
+type txGuard struct {
+	lock *txLock
+	once sync.Once
+}
+
+// unlock will release the lock
+func (g *txGuard) unlock() {
+	g.once.Do(g.lock.unlock)
+}
+
+// lockedResult is state of a result that hold lock of its transaction until it is closed
+// calls on the result are serialized by mx, so it can be closed by another goroutine
+// when its transaction is ended, rows and row that are read from batch results share its state
+type lockedResult struct {
+	mx     sync.Mutex
+	closed bool
+
+	// err is set when result is closed because its transaction is ended
+	err error
+
+	// closeRequested is set when transaction is ended while a call is reading the result
+	closeRequested atomic.Bool
+
+	// close will close the underlying result
+	close func()
+	guard *txGuard
+}
+
+// newLockedResult will create a result that hold the lock of guard
+// until it is closed or its transaction is ended
+func newLockedResult(guard *txGuard, close func()) *lockedResult {
+	r := &lockedResult{close: close, guard: guard}
+	guard.lock.holdResult(r.closeByEnd)
+	return r
+}
+
+// enter will serialize a call on the result
+func (r *lockedResult) enter() {
+	r.mx.Lock()
+}
+
+// leave will end a call on the result
+// result is closed when its transaction is ended while the call is running
+func (r *lockedResult) leave() {
+	r.mx.Unlock()
+	if r.closeRequested.Load() && r.mx.TryLock() {
+		r.release(ErrTxPoolResultClosed)
+		r.mx.Unlock()
+	}
+}
+
+// release will mark the result as closed and release lock of its transaction, r.mx must be held
+// when err is not nil the underlying result is closed first
+func (r *lockedResult) release(err error) {
+	if r.closed {
+		return
+	}
+	if err != nil {
+		r.close()
+	}
+	r.closed, r.err = true, err
+	r.guard.unlock()
+}
+
+// closeByEnd will close the result because its transaction is ended
+// it never wait, result that is read by a call is closed when the call return
+func (r *lockedResult) closeByEnd() {
+	r.closeRequested.Store(true)
+	if r.mx.TryLock() {
+		r.release(ErrTxPoolResultClosed)
+		r.mx.Unlock()
+	}
+}
+
+// lockedRows is a pgx.Rows that hold lock of its transaction until it is closed
+// rows that are read from batch results do not hold the lock, their batch results do,
+// rows is nil when batch results are closed because transaction is ended
+type lockedRows struct {
+	rows      pgx.Rows
+	result    *lockedResult
+	fromBatch bool
+}
+
+// newLockedRows will create rows that hold the lock of guard until they are closed
+func newLockedRows(rows pgx.Rows, guard *txGuard) *lockedRows {
+	return &lockedRows{rows: rows, result: newLockedResult(guard, rows.Close)}
+}
+
+// Close will close the rows and release the lock
+func (r *lockedRows) Close() {
+	r.result.enter()
+	defer r.result.leave()
+	if r.result.err != nil {
+		return
+	}
+	r.rows.Close()
+	if !r.fromBatch {
+		r.result.release(nil)
+	}
+}
+
+// Err will return ErrTxPoolResultClosed when rows are closed because transaction is ended
+func (r *lockedRows) Err() error {
+	r.result.enter()
+	defer r.result.leave()
+	if r.result.err != nil {
+		return r.result.err
+	}
+	return r.rows.Err()
+}
+
+func (r *lockedRows) CommandTag() pgconn.CommandTag {
+	r.result.enter()
+	defer r.result.leave()
+	if r.rows == nil {
+		return pgconn.CommandTag{}
+	}
+	return r.rows.CommandTag()
+}
+
+func (r *lockedRows) FieldDescriptions() []pgconn.FieldDescription {
+	r.result.enter()
+	defer r.result.leave()
+	if r.rows == nil {
+		return nil
+	}
+	return r.rows.FieldDescriptions()
+}
+
+// Next will release the lock when there is no more row,
+// because pgx close rows when Next return false
+func (r *lockedRows) Next() bool {
+	r.result.enter()
+	defer r.result.leave()
+	if r.result.err != nil {
+		return false
+	}
+	if r.rows.Next() {
+		return true
+	}
+	if !r.fromBatch {
+		r.result.release(nil)
+	}
+	return false
+}
+
+func (r *lockedRows) Scan(dest ...any) error {
+	r.result.enter()
+	defer r.result.leave()
+	if r.result.err != nil {
+		return r.result.err
+	}
+	return r.rows.Scan(dest...)
+}
+
+func (r *lockedRows) Values() ([]any, error) {
+	r.result.enter()
+	defer r.result.leave()
+	if r.result.err != nil {
+		return nil, r.result.err
+	}
+	return r.rows.Values()
+}
+
+func (r *lockedRows) RawValues() [][]byte {
+	r.result.enter()
+	defer r.result.leave()
+	if r.result.err != nil {
+		return nil
+	}
+	return r.rows.RawValues()
+}
+
+func (r *lockedRows) Conn() *pgx.Conn {
+	if r.rows == nil {
+		return nil
+	}
+	return r.rows.Conn()
+}
+
+// lockedRow is a pgx.Row that hold lock of its transaction until it is scanned
+// row that is read from batch results does not hold the lock, its batch results do
+type lockedRow struct {
+	row       pgx.Row
+	result    *lockedResult
+	fromBatch bool
+}
+
+// newLockedRow will create a row that hold the lock of guard until it is scanned
+// pgx close the row when it is scanned even without destinations
+func newLockedRow(row pgx.Row, guard *txGuard) *lockedRow {
+	return &lockedRow{row: row, result: newLockedResult(guard, func() { row.Scan() })}
+}
+
+// Scan will scan the row and release the lock
+func (r *lockedRow) Scan(dest ...any) error {
+	r.result.enter()
+	defer r.result.leave()
+	if r.result.err != nil {
+		return r.result.err
+	}
+	err := r.row.Scan(dest...)
+	if !r.fromBatch {
+		r.result.release(nil)
+	}
+	return err
+}
+
+// lockedBatchResults is a pgx.BatchResults that hold lock of its transaction until it is closed
+type lockedBatchResults struct {
+	results pgx.BatchResults
+	result  *lockedResult
+
+	// rows is the last rows read from results, it is closed before results
+	// when transaction is ended
+	rows pgx.Rows
+}
+
+// newLockedBatchResults will create batch results that hold the lock of guard until they are closed
+func newLockedBatchResults(results pgx.BatchResults, guard *txGuard) *lockedBatchResults {
+	b := &lockedBatchResults{results: results}
+	b.result = newLockedResult(guard, func() {
+		if b.rows != nil {
+			b.rows.Close()
+		}
+		b.results.Close()
+	})
+	return b
+}
+
+func (b *lockedBatchResults) Exec() (pgconn.CommandTag, error) {
+	b.result.enter()
+	defer b.result.leave()
+	if b.result.err != nil {
+		return pgconn.CommandTag{}, b.result.err
+	}
+	return b.results.Exec()
+}
+
+func (b *lockedBatchResults) Query() (pgx.Rows, error) {
+	b.result.enter()
+	defer b.result.leave()
+	if b.result.err != nil {
+		return &lockedRows{result: b.result, fromBatch: true}, b.result.err
+	}
+	rows, err := b.results.Query()
+	b.rows = rows
+	return &lockedRows{rows: rows, result: b.result, fromBatch: true}, err
+}
+
+func (b *lockedBatchResults) QueryRow() pgx.Row {
+	b.result.enter()
+	defer b.result.leave()
+	if b.result.err != nil {
+		return errRow{err: b.result.err}
+	}
+	return &lockedRow{row: b.results.QueryRow(), result: b.result, fromBatch: true}
+}
+
+// Close will close the batch results and release the lock
+func (b *lockedBatchResults) Close() error {
+	b.result.enter()
+	defer b.result.leave()
+	if b.result.err != nil {
+		return nil
+	}
+	err := b.results.Close()
+	b.result.release(nil)
+	return err
+}
+
+var (
+	_ pgx.Rows         = (*lockedRows)(nil)
+	_ pgx.Row          = (*lockedRow)(nil)
+	_ pgx.BatchResults = (*lockedBatchResults)(nil)
+)
@@ -0,0 +1,439 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// exclusiveTx is a fakeTx that record when statements run concurrently,
+// pgx.Tx does not allow it and return conn busy
+type exclusiveTx struct {
+	fakeTx
+	running atomic.Int32
+	overlap atomic.Bool
+}
+
+func (e *exclusiveTx) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	if e.running.Add(1) > 1 {
+		e.overlap.Store(true)
+	}
+	defer e.running.Add(-1)
+	time.Sleep(time.Millisecond)
+	return e.fakeTx.Exec(ctx, sql, arguments...)
+}
+
+// newExclusiveTXContext will register an exclusiveTx in the pool
+// and return context that carry its id
+func newExclusiveTXContext(p *Pool) (context.Context, *exclusiveTx) {
+	tx := &exclusiveTx{}
+	txID := generateID(context.Background())
+	p.storeTXConn(txID, &txConn{kind: TxKindTransaction, tx: tx, startedAt: time.Now(), lock: newTXLock()})
+	return p.ContextWithTxID(context.Background(), txID), tx
+}
+
+// blockingTx is a fakeTx whose Exec run until release is closed
+// it record when transaction is ended while Exec is running
+type blockingTx struct {
+	fakeTx
+	release chan struct{}
+	running atomic.Bool
+	overlap atomic.Bool
+}
+
+func newBlockingTx() *blockingTx {
+	return &blockingTx{release: make(chan struct{})}
+}
+
+func (b *blockingTx) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	b.running.Store(true)
+	defer b.running.Store(false)
+	<-b.release
+	return b.fakeTx.Exec(ctx, sql, arguments...)
+}
+
+func (b *blockingTx) Commit(ctx context.Context) error {
+	b.overlap.CompareAndSwap(false, b.running.Load())
+	return b.fakeTx.Commit(ctx)
+}
+
+func (b *blockingTx) Rollback(ctx context.Context) error {
+	b.overlap.CompareAndSwap(false, b.running.Load())
+	return b.fakeTx.Rollback(ctx)
+}
+
+// execAsync will run Exec in another goroutine
+// and return channel that is closed when it is done
+func execAsync(p *Pool, ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Exec(ctx, "SELECT 1")
+	}()
+	return done
+}
+
+// queryAsync will open rows in another goroutine
+// so the lock is held by a goroutine other than the caller
+func queryAsync(p *Pool, ctx context.Context) pgx.Rows {
+	result := make(chan pgx.Rows)
+	go func() {
+		rows, _ := p.Query(ctx, "SELECT 1")
+		result <- rows
+	}()
+	return <-result
+}
+
+// isBlocked will report whether done is not closed in a short time
+func isBlocked(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return false
+	case <-time.After(20 * time.Millisecond):
+		return true
+	}
+}
+
+func TestTXLock(t *testing.T) {
+	t.Run("should queue concurrent statements on the same transaction", func(t *testing.T) {
+		p := &Pool{generateID: generateID}
+		ctx, tx := newExclusiveTXContext(p)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p.Exec(ctx, "SELECT 1")
+			}()
+		}
+		wg.Wait()
+
+		if tx.overlap.Load() {
+			t.Log("statements should not run concurrently on the same transaction")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should hold the lock until rows are closed", func(t *testing.T) {
+		p := &Pool{generateID: generateID}
+		ctx, _ := newFakeTXContext(p)
+
+		rows, err := p.Query(ctx, "SELECT 1")
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		done := execAsync(p, ctx)
+		if !isBlocked(done) {
+			t.Log("statement should wait until rows are closed")
+			t.FailNow()
+		}
+		rows.Close()
+		<-done
+
+		// rows that are closed twice should not release lock held by another rows
+		other, _ := p.Query(ctx, "SELECT 1")
+		defer other.Close()
+		rows.Close()
+		if !isBlocked(execAsync(p, ctx)) {
+			t.Log("closing rows twice should release the lock only once")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should release the lock when rows are exhausted or row is scanned", func(t *testing.T) {
+		p := &Pool{generateID: generateID}
+		ctx, _ := newFakeTXContext(p)
+
+		rows, err := p.Query(ctx, "SELECT 1")
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		for rows.Next() {
+		}
+
+		var n int
+		p.QueryRow(ctx, "SELECT 1").Scan(&n)
+
+		if err := p.CommitTX(ctx); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should stop waiting when context is done", func(t *testing.T) {
+		p := &Pool{generateID: generateID}
+		ctx, _ := newFakeTXContext(p)
+
+		rows := queryAsync(p, ctx)
+		defer rows.Close()
+
+		waitCTX, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		if _, err := p.Exec(waitCTX, "SELECT 1"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Logf("expected error %v, got %v", context.DeadlineExceeded, err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should fail fast when transaction is busy", func(t *testing.T) {
+		p := &Pool{generateID: generateID, failFastOnBusyTX: true}
+		ctx, _ := newFakeTXContext(p)
+
+		rows := queryAsync(p, ctx)
+		defer rows.Close()
+
+		if _, err := p.Exec(ctx, "SELECT 1"); !errors.Is(err, ErrTxPoolTxBusy) {
+			t.Logf("expected error %v, got %v", ErrTxPoolTxBusy, err)
+			t.FailNow()
+		}
+		var n int
+		if err := p.QueryRow(ctx, "SELECT 1").Scan(&n); !errors.Is(err, ErrTxPoolTxBusy) {
+			t.Logf("expected error %v, got %v", ErrTxPoolTxBusy, err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should wait for rows handed off to another goroutine", func(t *testing.T) {
+		p := &Pool{generateID: generateID}
+		ctx, _ := newFakeTXContext(p)
+
+		rows, _ := p.Query(ctx, "SELECT 1")
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			rows.Close()
+		}()
+
+		if _, err := p.Exec(ctx, "SELECT 1"); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should close rows and row leaked by the calling goroutine when transaction is ended", func(t *testing.T) {
+		p := &Pool{generateID: generateID}
+
+		commitCTX, commitTx := newFakeTXContext(p)
+		commitConn, _ := p.getTXConnFromContext(commitCTX)
+		p.Query(commitCTX, "SELECT 1")
+		if err := p.CommitTX(commitCTX); err != nil || !commitTx.called("Commit") {
+			t.Logf("transaction should be committed, got %v", err)
+			t.FailNow()
+		}
+
+		rollbackCTX, rollbackTx := newFakeTXContext(p)
+		rollbackConn, _ := p.getTXConnFromContext(rollbackCTX)
+		p.QueryRow(rollbackCTX, "SELECT 1")
+		if err := p.RollbackTX(rollbackCTX); err != nil || !rollbackTx.called("Rollback") {
+			t.Logf("transaction should be rolled back, got %v", err)
+			t.FailNow()
+		}
+
+		if commitConn.lock.lock(context.Background(), true) != nil || rollbackConn.lock.lock(context.Background(), true) != nil {
+			t.Log("lock should be released after transaction is ended")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should close rows and batch results of another goroutine when transaction is ended", func(t *testing.T) {
+		p := &Pool{generateID: generateID}
+		ctx, tx := newFakeTXContext(p)
+
+		rows := queryAsync(p, ctx)
+
+		done := make(chan error, 1)
+		go func() { done <- p.CommitTX(ctx) }()
+		select {
+		case err := <-done:
+			if err != nil || !tx.called("Commit") {
+				t.Logf("transaction should be committed, got %v", err)
+				t.FailNow()
+			}
+		case <-time.After(time.Second):
+			t.Log("commit should not wait for rows that are not closed")
+			t.FailNow()
+		}
+		if rows.Next() || !errors.Is(rows.Err(), ErrTxPoolResultClosed) {
+			t.Logf("expected error %v, got %v", ErrTxPoolResultClosed, rows.Err())
+			t.FailNow()
+		}
+		rows.Close()
+
+		batchCTX, batchTx := newFakeTXContext(p)
+		results := make(chan pgx.BatchResults)
+		go func() { results <- p.SendBatch(batchCTX, &pgx.Batch{}) }()
+		batch := <-results
+		if err := p.RollbackTX(batchCTX); err != nil || !batchTx.called("Rollback") {
+			t.Logf("transaction should be rolled back, got %v", err)
+			t.FailNow()
+		}
+		if _, err := batch.Exec(); !errors.Is(err, ErrTxPoolResultClosed) {
+			t.Logf("expected error %v, got %v", ErrTxPoolResultClosed, err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should remove transaction when closure return while rows of its worker are open", func(t *testing.T) {
+		p, begun := newFakePool()
+		boom := errors.New("boom")
+
+		var rows pgx.Rows
+		err := p.WithTransaction(context.Background(), func(ctx context.Context) error {
+			rows = queryAsync(p, ctx)
+			return boom
+		})
+		if !errors.Is(err, boom) || errors.Is(err, ErrTxPoolTxBusy) {
+			t.Logf("expected error %v, got %v", boom, err)
+			t.FailNow()
+		}
+		if len(p.ActiveTransactions()) != 0 || !(*begun)[0].called("Rollback") {
+			t.Log("transaction should be rolled back and removed from the pool")
+			t.FailNow()
+		}
+		if !errors.Is(rows.Err(), ErrTxPoolResultClosed) {
+			t.Logf("expected error %v, got %v", ErrTxPoolResultClosed, rows.Err())
+			t.FailNow()
+		}
+	})
+
+	t.Run("should rollback after running statement when commit context is done", func(t *testing.T) {
+		p := &Pool{generateID: generateID}
+		tx := newBlockingTx()
+		txID := generateID(context.Background())
+		p.storeTXConn(txID, &txConn{kind: TxKindTransaction, tx: tx, startedAt: time.Now(), lock: newTXLock()})
+		ctx := p.ContextWithTxID(context.Background(), txID)
+
+		done := execAsync(p, ctx)
+		waitUntil(t, tx.running.Load)
+
+		commitCTX, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		err := p.CommitTX(commitCTX)
+		if !errors.Is(err, ErrTxPoolTxBusy) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Logf("expected error %v, got %v", ErrTxPoolTxBusy, err)
+			t.FailNow()
+		}
+		if len(p.ActiveTransactions()) != 0 || tx.called("Rollback") {
+			t.Log("transaction should be removed, but not rolled back while statement is running")
+			t.FailNow()
+		}
+
+		close(tx.release)
+		<-done
+		if !tx.called("Rollback") || tx.called("Commit") || tx.overlap.Load() {
+			t.Log("transaction should be rolled back after statement return")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should share the lock with savepoint and joined transaction", func(t *testing.T) {
+		p := &Pool{generateID: generateID}
+		ctx, _ := newFakeTXContext(p)
+
+		savepointCTX, err := p.BeginTX(ctx)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		joinedCTX, err := p.BeginTXWithPropagation(ctx, PropagationRequired, pgx.TxOptions{})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		rows, _ := p.Query(savepointCTX, "SELECT 1")
+		if !isBlocked(execAsync(p, joinedCTX)) {
+			t.Log("joined transaction should wait for rows of savepoint")
+			t.FailNow()
+		}
+		rows.Close()
+	})
+
+	t.Run("should reap transaction that has rows that are not closed", func(t *testing.T) {
+		p, begun := newFakePool()
+		p.reaper = &reaper{maxTxLifetime: time.Minute, onTxReaped: func(txID TxID, age time.Duration) {}}
+
+		ctx, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		conn, _ := p.getTXConnFromContext(ctx)
+		conn.startedAt = time.Now().Add(-2 * time.Minute)
+
+		rows, _ := p.Query(ctx, "SELECT 1")
+		p.reapTX()
+		if !(*begun)[0].called("Rollback") || p.TxStats().Reaped != 1 || len(p.ActiveTransactions()) != 0 {
+			t.Log("transaction should be reaped and its rows closed")
+			t.FailNow()
+		}
+		if !errors.Is(rows.Err(), ErrTxPoolResultClosed) {
+			t.Logf("expected error %v, got %v", ErrTxPoolResultClosed, rows.Err())
+			t.FailNow()
+		}
+	})
+
+	t.Run("should abort transaction that has rows that are not closed when context is done", func(t *testing.T) {
+		p, begun := newFakePool()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		txCTX, err := p.BeginTX(ctx)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		p.Query(txCTX, "SELECT 1")
+		cancel()
+
+		waitUntil(t, func() bool { return p.TxStats().Aborted == 1 })
+		if !(*begun)[0].called("Rollback") || len(p.ActiveTransactions()) != 0 {
+			t.Log("transaction should be rolled back and removed from the pool")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should abort transaction after running statement when context is done", func(t *testing.T) {
+		p, _ := newFakePool()
+		tx := newBlockingTx()
+		p.beginTx = func(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+			return tx, nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		txCTX, err := p.BeginTX(ctx)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		done := execAsync(p, txCTX)
+		waitUntil(t, tx.running.Load)
+		cancel()
+
+		waitUntil(t, func() bool { return p.TxStats().Aborted == 1 })
+		if err := p.CommitTX(txCTX); !errors.Is(err, ErrTxPoolAbortedByContext) {
+			t.Logf("expected error %v, got %v", ErrTxPoolAbortedByContext, err)
+			t.FailNow()
+		}
+		if tx.called("Rollback") {
+			t.Log("transaction should not be rolled back while statement is running")
+			t.FailNow()
+		}
+
+		close(tx.release)
+		<-done
+		if !tx.called("Rollback") || tx.overlap.Load() {
+			t.Log("transaction should be rolled back after statement return")
+			t.FailNow()
+		}
+	})
+
+}
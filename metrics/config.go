@@ -0,0 +1,43 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// default histogram buckets
+var (
+	// defaultDurationBuckets is in seconds, from 1ms to 30s
+	defaultDurationBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+	// defaultStatementBuckets is number of statements executed in a transaction
+	defaultStatementBuckets = []float64{0, 1, 2, 5, 10, 25, 50, 100}
+)
+
+type config struct {
+	durationBuckets  []float64
+	statementBuckets []float64
+	constLabels      prometheus.Labels
+}
+
+// Option is a function that can be used to configure the Collector
+type Option func(*config)
+
+// WithDurationBuckets will set buckets of transaction duration histogram in seconds
+func WithDurationBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.durationBuckets = buckets
+	}
+}
+
+// WithStatementBuckets will set buckets of statements per transaction histogram
+func WithStatementBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.statementBuckets = buckets
+	}
+}
+
+// WithConstLabels will set labels that added to every metric
+// use it to distinguish multiple pools registered on the same registerer
+// ex: prometheus.Labels{"pool": "primary"}
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
@@ -0,0 +1,205 @@
+// Package metrics expose transactions of pgxtxpool.Pool as prometheus metrics
+// along with connection stats from pgxpool.Stat
+// ex:
+//
+//	collector := metrics.NewCollector()
+//	pool := pgxtxpool.New(
+//		pgxtxpool.SetHost("localhost", "5432"),
+//		pgxtxpool.WithHooks(collector),
+//	)
+//	err := collector.Register(prometheus.DefaultRegisterer, pool)
+//
+// collector is added as hooks so it can observe duration and statements of ended transactions,
+// other metrics are read from the pool when collected
+package metrics
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pgxtxpool "github.com/rasatmaja/pgx-txpool"
+)
+
+// namespace is prefix of every metric name
+const namespace = "pgxtxpool"
+
+// Collector is a prometheus.Collector for a pgxtxpool.Pool
+type Collector struct {
+	pgxtxpool.NoopHooks
+
+	pool atomic.Pointer[pgxtxpool.Pool]
+
+	duration   prometheus.Histogram
+	statements prometheus.Histogram
+
+	// transaction registry
+	openTransactions *prometheus.Desc
+	oldestAge        *prometheus.Desc
+	begun            *prometheus.Desc
+	committed        *prometheus.Desc
+	rolledBack       *prometheus.Desc
+	reaped           *prometheus.Desc
+	aborted          *prometheus.Desc
+	retried          *prometheus.Desc
+
+	// pgxpool.Stat
+	acquiredConns           *prometheus.Desc
+	idleConns               *prometheus.Desc
+	constructingConns       *prometheus.Desc
+	totalConns              *prometheus.Desc
+	maxConns                *prometheus.Desc
+	acquireCount            *prometheus.Desc
+	acquireDuration         *prometheus.Desc
+	canceledAcquireCount    *prometheus.Desc
+	emptyAcquireCount       *prometheus.Desc
+	newConnsCount           *prometheus.Desc
+	maxLifetimeDestroyCount *prometheus.Desc
+	maxIdleDestroyCount     *prometheus.Desc
+}
+
+var (
+	_ prometheus.Collector = (*Collector)(nil)
+	_ pgxtxpool.Hooks      = (*Collector)(nil)
+	_ pgxtxpool.TxEndHooks = (*Collector)(nil)
+)
+
+// NewCollector will create a Collector
+// it should be added to the pool using pgxtxpool.WithHooks
+// then registered using Register once the pool is created
+func NewCollector(opts ...Option) *Collector {
+	config := config{
+		durationBuckets:  defaultDurationBuckets,
+		statementBuckets: defaultStatementBuckets,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, nil, config.constLabels)
+	}
+
+	return &Collector{
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "transaction_duration_seconds",
+			Help:        "Duration of ended transactions from begin until commit or rollback.",
+			Buckets:     config.durationBuckets,
+			ConstLabels: config.constLabels,
+		}),
+		statements: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "transaction_statements",
+			Help:        "Number of statements executed by ended transactions.",
+			Buckets:     config.statementBuckets,
+			ConstLabels: config.constLabels,
+		}),
+
+		openTransactions: desc("transactions_open", "Number of transactions registered in the pool."),
+		oldestAge:        desc("transaction_oldest_age_seconds", "Age of the oldest transaction registered in the pool."),
+		begun:            desc("transactions_begun_total", "Number of transactions begun."),
+		committed:        desc("transactions_committed_total", "Number of transactions committed."),
+		rolledBack:       desc("transactions_rolled_back_total", "Number of transactions rolled back."),
+		reaped:           desc("transactions_reaped_total", "Number of transactions rolled back by the reaper."),
+		aborted:          desc("transactions_aborted_total", "Number of transactions rolled back because their context is done or the pool is shut down."),
+		retried:          desc("transactions_retried_total", "Number of transaction attempts that failed and are run again."),
+
+		acquiredConns:           desc("pool_acquired_conns", "Number of currently acquired connections in the pool."),
+		idleConns:               desc("pool_idle_conns", "Number of currently idle connections in the pool."),
+		constructingConns:       desc("pool_constructing_conns", "Number of connections with construction in progress in the pool."),
+		totalConns:              desc("pool_total_conns", "Total number of resources currently in the pool."),
+		maxConns:                desc("pool_max_conns", "Maximum size of the pool."),
+		acquireCount:            desc("pool_acquire_count_total", "Number of successful acquires from the pool."),
+		acquireDuration:         desc("pool_acquire_duration_seconds_total", "Total duration of all successful acquires from the pool."),
+		canceledAcquireCount:    desc("pool_canceled_acquire_count_total", "Number of acquires from the pool that were canceled by a context."),
+		emptyAcquireCount:       desc("pool_empty_acquire_count_total", "Number of successful acquires from the pool that waited for a resource."),
+		newConnsCount:           desc("pool_new_conns_count_total", "Number of new connections opened."),
+		maxLifetimeDestroyCount: desc("pool_max_lifetime_destroy_count_total", "Number of connections destroyed due to MaxConnLifetime."),
+		maxIdleDestroyCount:     desc("pool_max_idle_destroy_count_total", "Number of connections destroyed due to MaxConnIdleTime."),
+	}
+}
+
+// Register will bind the collector to pool and register it on registerer
+func (c *Collector) Register(registerer prometheus.Registerer, pool *pgxtxpool.Pool) error {
+	c.pool.Store(pool)
+	return registerer.Register(c)
+}
+
+// OnTxEnd will observe duration and number of statements of an ended transaction
+func (c *Collector) OnTxEnd(ctx context.Context, info pgxtxpool.TxInfo) {
+	c.duration.Observe(time.Since(info.StartedAt).Seconds())
+	c.statements.Observe(float64(info.QueryCount))
+}
+
+// Describe will send descriptors of every metric
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.duration.Describe(ch)
+	c.statements.Describe(ch)
+	for _, desc := range []*prometheus.Desc{
+		c.openTransactions, c.oldestAge, c.begun, c.committed, c.rolledBack, c.reaped, c.aborted, c.retried,
+		c.acquiredConns, c.idleConns, c.constructingConns, c.totalConns, c.maxConns,
+		c.acquireCount, c.acquireDuration, c.canceledAcquireCount, c.emptyAcquireCount,
+		c.newConnsCount, c.maxLifetimeDestroyCount, c.maxIdleDestroyCount,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect will send current value of every metric
+// metrics from the pool are only sent when collector is registered using Register
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.duration.Collect(ch)
+	c.statements.Collect(ch)
+
+	pool := c.pool.Load()
+	if pool == nil {
+		return
+	}
+	c.collectTransactions(ch, pool)
+	c.collectPoolStat(ch, pool)
+}
+
+// collectTransactions will send metrics of transaction registry
+// savepoint, joined and scope without transaction are not counted as open transaction
+func (c *Collector) collectTransactions(ch chan<- prometheus.Metric, pool *pgxtxpool.Pool) {
+	var open int
+	var oldestAge time.Duration
+	for _, info := range pool.ActiveTransactions() {
+		if info.Kind != pgxtxpool.TxKindTransaction {
+			continue
+		}
+		open++
+		if age := time.Since(info.StartedAt); age > oldestAge {
+			oldestAge = age
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(c.openTransactions, prometheus.GaugeValue, float64(open))
+	ch <- prometheus.MustNewConstMetric(c.oldestAge, prometheus.GaugeValue, oldestAge.Seconds())
+
+	stats := pool.TxStats()
+	ch <- prometheus.MustNewConstMetric(c.begun, prometheus.CounterValue, float64(stats.Begun))
+	ch <- prometheus.MustNewConstMetric(c.committed, prometheus.CounterValue, float64(stats.Committed))
+	ch <- prometheus.MustNewConstMetric(c.rolledBack, prometheus.CounterValue, float64(stats.RolledBack))
+	ch <- prometheus.MustNewConstMetric(c.reaped, prometheus.CounterValue, float64(stats.Reaped))
+	ch <- prometheus.MustNewConstMetric(c.aborted, prometheus.CounterValue, float64(stats.Aborted))
+	ch <- prometheus.MustNewConstMetric(c.retried, prometheus.CounterValue, float64(stats.Retried))
+}
+
+// collectPoolStat will send connection metrics from pgxpool.Stat
+func (c *Collector) collectPoolStat(ch chan<- prometheus.Metric, pool *pgxtxpool.Pool) {
+	stat := pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.constructingConns, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquireCount, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.newConnsCount, prometheus.CounterValue, float64(stat.NewConnsCount()))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeDestroyCount, prometheus.CounterValue, float64(stat.MaxLifetimeDestroyCount()))
+	ch <- prometheus.MustNewConstMetric(c.maxIdleDestroyCount, prometheus.CounterValue, float64(stat.MaxIdleDestroyCount()))
+}
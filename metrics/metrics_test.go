@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	pgxtxpool "github.com/rasatmaja/pgx-txpool"
+)
+
+// newTestPool will create a pool that never connect to database
+// because pgxpool only open connection when it is acquired
+func newTestPool(t *testing.T, collector *Collector) *pgxtxpool.Pool {
+	pool := pgxtxpool.New(
+		pgxtxpool.SetHost("localhost", "5432"),
+		pgxtxpool.WithMaxConns(7),
+		pgxtxpool.WithHooks(collector),
+	)
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestCollector(t *testing.T) {
+	t.Run("should register and expose transaction and pool metrics", func(t *testing.T) {
+		collector := NewCollector(WithConstLabels(prometheus.Labels{"pool": "primary"}))
+		registry := prometheus.NewPedanticRegistry()
+		if err := collector.Register(registry, newTestPool(t, collector)); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		expected := `
+# HELP pgxtxpool_pool_max_conns Maximum size of the pool.
+# TYPE pgxtxpool_pool_max_conns gauge
+pgxtxpool_pool_max_conns{pool="primary"} 7
+# HELP pgxtxpool_transactions_committed_total Number of transactions committed.
+# TYPE pgxtxpool_transactions_committed_total counter
+pgxtxpool_transactions_committed_total{pool="primary"} 0
+# HELP pgxtxpool_transactions_open Number of transactions registered in the pool.
+# TYPE pgxtxpool_transactions_open gauge
+pgxtxpool_transactions_open{pool="primary"} 0
+`
+		err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+			"pgxtxpool_pool_max_conns",
+			"pgxtxpool_transactions_committed_total",
+			"pgxtxpool_transactions_open",
+		)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		// 2 histograms, 8 transaction metrics and 12 pool metrics
+		if count := testutil.CollectAndCount(collector); count != 22 {
+			t.Logf("expected 22 metrics, got %d", count)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should observe duration and statements of ended transaction", func(t *testing.T) {
+		collector := NewCollector(
+			WithDurationBuckets([]float64{1, 10}),
+			WithStatementBuckets([]float64{1, 5}),
+		)
+
+		collector.OnTxEnd(context.Background(), pgxtxpool.TxInfo{StartedAt: time.Now().Add(-2 * time.Second), QueryCount: 3})
+		collector.OnTxEnd(context.Background(), pgxtxpool.TxInfo{StartedAt: time.Now(), QueryCount: 1})
+
+		expected := `
+# HELP pgxtxpool_transaction_statements Number of statements executed by ended transactions.
+# TYPE pgxtxpool_transaction_statements histogram
+pgxtxpool_transaction_statements_bucket{le="1"} 1
+pgxtxpool_transaction_statements_bucket{le="5"} 2
+pgxtxpool_transaction_statements_bucket{le="+Inf"} 2
+pgxtxpool_transaction_statements_sum 4
+pgxtxpool_transaction_statements_count 2
+`
+		err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "pgxtxpool_transaction_statements")
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		// pool is not bound, only histograms are collected
+		if count := testutil.CollectAndCount(collector, "pgxtxpool_transaction_duration_seconds"); count != 1 {
+			t.Logf("expected duration histogram, got %d metrics", count)
+			t.FailNow()
+		}
+		if count := testutil.CollectAndCount(collector); count != 2 {
+			t.Logf("expected only 2 histograms without pool, got %d", count)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should fail to register twice on the same registerer", func(t *testing.T) {
+		collector := NewCollector()
+		pool := newTestPool(t, collector)
+		registry := prometheus.NewRegistry()
+		if err := collector.Register(registry, pool); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err := NewCollector().Register(registry, pool); err == nil {
+			t.Log("expected error when registering duplicate metrics")
+			t.FailNow()
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package outbox
+
+import (
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultTable        = "outbox"
+	defaultBatchSize    = 100
+	defaultPollInterval = time.Second
+	defaultMaxAttempts  = 10
+	defaultBaseDelay    = time.Second
+	defaultMaxDelay     = 5 * time.Minute
+)
+
+type config struct {
+	table        string
+	batchSize    int
+	pollInterval time.Duration
+	maxAttempts  int
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+	onError      func(err error)
+}
+
+// backoff will return delay before a message is retried
+// attempts is number of failed attempts, start from 1
+// ex: attempts 3 with base delay 1s will wait 4s
+func (c *config) backoff(attempts int) time.Duration {
+	delay := c.baseDelay << (attempts - 1)
+	if delay <= 0 || delay > c.maxDelay {
+		delay = c.maxDelay
+	}
+	return delay
+}
+
+// Option is a function that can be used to configure outbox
+type Option func(*config)
+
+// WithTable will set outbox table name
+// default is "outbox"
+func WithTable(table string) Option {
+	return func(c *config) {
+		c.table = table
+	}
+}
+
+// WithBatchSize will set maximum number of messages claimed by relay at once
+// default is 100
+func WithBatchSize(batchSize int) Option {
+	return func(c *config) {
+		c.batchSize = batchSize
+	}
+}
+
+// WithPollInterval will set how long relay wait before claiming messages again
+// when there is no more message to publish
+// default is 1s
+func WithPollInterval(pollInterval time.Duration) Option {
+	return func(c *config) {
+		c.pollInterval = pollInterval
+	}
+}
+
+// WithMaxAttempts will set maximum number of publish attempts
+// message that still failed after maximum attempts is moved to dead letter (StatusDead)
+// default is 10 attempts
+func WithMaxAttempts(maxAttempts int) Option {
+	return func(c *config) {
+		c.maxAttempts = maxAttempts
+	}
+}
+
+// WithBackoff will set base and maximum delay before failed message is retried
+// default is 1s base delay and 5m maximum delay
+func WithBackoff(baseDelay, maxDelay time.Duration) Option {
+	return func(c *config) {
+		c.baseDelay = baseDelay
+		c.maxDelay = maxDelay
+	}
+}
+
+// WithErrorHandler will set a function that called when relay failed to claim or update messages
+// by default error is logged using slog default logger
+func WithErrorHandler(onError func(err error)) Option {
+	return func(c *config) {
+		c.onError = onError
+	}
+}
+
+// logError will log relay error using slog default logger
+func logError(err error) {
+	slog.Error("pgxtxpool/outbox: relay failed", "error", err)
+}
@@ -0,0 +1,27 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	cfg := config{baseDelay: time.Second, maxDelay: 5 * time.Second}
+	cases := []struct {
+		attempts int
+		expDelay time.Duration
+	}{
+		{attempts: 1, expDelay: time.Second},
+		{attempts: 2, expDelay: 2 * time.Second},
+		{attempts: 3, expDelay: 4 * time.Second},
+		{attempts: 4, expDelay: 5 * time.Second},
+		{attempts: 100, expDelay: 5 * time.Second},
+	}
+
+	for _, c := range cases {
+		if delay := cfg.backoff(c.attempts); delay != c.expDelay {
+			t.Logf("attempts %d: expected delay %s, got %s", c.attempts, c.expDelay, delay)
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package outbox implement transactional outbox on top of pgxtxpool.Pool
+// message is written to outbox table inside the transaction carried in the context,
+// so it is only published when the transaction is committed
+// then a relay claim unsent message and hand it to a Publisher
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	pgxtxpool "github.com/rasatmaja/pgx-txpool"
+)
+
+// Status is delivery status of a message in outbox table
+type Status string
+
+const (
+	// StatusPending is a message that waiting to be published
+	StatusPending Status = "pending"
+	// StatusSent is a message that has been published
+	StatusSent Status = "sent"
+	// StatusDead is a message that failed to be published after maximum attempts
+	StatusDead Status = "dead"
+)
+
+// Message is a message that stored in outbox table
+// messages with the same AggregateKey are published in order they are stored
+type Message struct {
+	ID           int64
+	AggregateKey string
+	Topic        string
+	Payload      []byte
+	Attempts     int
+	CreatedAt    time.Time
+}
+
+// Publisher will publish a message to message broker
+// returning an error will make the message retried with backoff
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// PublisherFunc is an adapter to use a function as Publisher
+type PublisherFunc func(ctx context.Context, msg Message) error
+
+// Publish will call f(ctx, msg)
+func (f PublisherFunc) Publish(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// Outbox is a transactional outbox that store and relay messages using pgxtxpool.Pool
+type Outbox struct {
+	pool   *pgxtxpool.Pool
+	config config
+	table  string
+}
+
+// New will create a new outbox
+func New(pool *pgxtxpool.Pool, opts ...Option) *Outbox {
+	config := config{
+		table:        defaultTable,
+		batchSize:    defaultBatchSize,
+		pollInterval: defaultPollInterval,
+		maxAttempts:  defaultMaxAttempts,
+		baseDelay:    defaultBaseDelay,
+		maxDelay:     defaultMaxDelay,
+		onError:      logError,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return &Outbox{
+		pool:   pool,
+		config: config,
+		table:  pgx.Identifier{config.table}.Sanitize(),
+	}
+}
+
+// Migrate will create outbox table and its index if not exists
+func (o *Outbox) Migrate(ctx context.Context) error {
+	index := pgx.Identifier{o.config.table + "_pending_idx"}.Sanitize()
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s (
+		id BIGSERIAL PRIMARY KEY,
+		aggregate_key TEXT NOT NULL,
+		topic TEXT NOT NULL,
+		payload BYTEA NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INT NOT NULL DEFAULT 0,
+		last_error TEXT,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		available_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		sent_at TIMESTAMPTZ
+	);
+	CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (aggregate_key, id) WHERE status = 'pending';
+	`, o.table, index)
+	_, err := o.pool.Exec(ctx, query)
+	return err
+}
+
+// Store will write messages to outbox table inside the transaction carried in the context
+// it will return pgxtxpool.ErrTxPoolTransactionRequired if context does not carry an active transaction
+func (o *Outbox) Store(ctx context.Context, msgs ...Message) error {
+	query := fmt.Sprintf(`INSERT INTO %s (aggregate_key, topic, payload) VALUES ($1, $2, $3)`, o.table)
+	return o.pool.WithTransactionPropagation(ctx, pgxtxpool.PropagationMandatory, pgx.TxOptions{}, func(ctx context.Context) error {
+		for _, msg := range msgs {
+			if _, err := o.pool.Exec(ctx, query, msg.AggregateKey, msg.Topic, msg.Payload); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,31 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pgxtxpool "github.com/rasatmaja/pgx-txpool"
+)
+
+func TestNew(t *testing.T) {
+	o := New(&pgxtxpool.Pool{}, WithTable("events outbox"), WithBatchSize(10))
+	if o.table != `"events outbox"` {
+		t.Logf("table name should be sanitized, got %s", o.table)
+		t.FailNow()
+	}
+
+	if o.config.batchSize != 10 || o.config.maxAttempts != defaultMaxAttempts {
+		t.Logf("unexpected config %+v", o.config)
+		t.FailNow()
+	}
+}
+
+func TestStoreWithoutTransaction(t *testing.T) {
+	o := New(&pgxtxpool.Pool{})
+	err := o.Store(context.Background(), Message{AggregateKey: "USR001", Topic: "user.created"})
+	if !errors.Is(err, pgxtxpool.ErrTxPoolTransactionRequired) {
+		t.Logf("expected error %v, got %v", pgxtxpool.ErrTxPoolTransactionRequired, err)
+		t.FailNow()
+	}
+}
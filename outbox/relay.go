@@ -0,0 +1,104 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Relay will publish pending messages until context is done
+// it claim messages in batch, then wait for poll interval when there is no more message
+func (o *Outbox) Relay(ctx context.Context, publisher Publisher) {
+	for {
+		published, err := o.RelayOnce(ctx, publisher)
+		if err != nil && ctx.Err() == nil {
+			o.config.onError(err)
+		}
+
+		// claim next batch immediately when current batch is full
+		if err == nil && published >= o.config.batchSize {
+			continue
+		}
+
+		timer := time.NewTimer(o.config.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// RelayOnce will claim a batch of pending messages and hand them to publisher
+// then mark them as sent, or schedule a retry when publisher return an error
+// only the oldest pending message of each aggregate key is claimed,
+// so messages with the same aggregate key are published in order
+// claimed messages are locked using FOR UPDATE SKIP LOCKED, so multiple relays can run at once
+// it return number of messages that handed to publisher
+func (o *Outbox) RelayOnce(ctx context.Context, publisher Publisher) (int, error) {
+	claim := fmt.Sprintf(`
+	SELECT o.id, o.aggregate_key, o.topic, o.payload, o.attempts, o.created_at
+	FROM %[1]s o
+	WHERE o.status = 'pending'
+		AND o.available_at <= now()
+		AND NOT EXISTS (
+			SELECT 1 FROM %[1]s e
+			WHERE e.aggregate_key = o.aggregate_key AND e.status = 'pending' AND e.id < o.id
+		)
+	ORDER BY o.id
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED`, o.table)
+
+	sent := fmt.Sprintf(`UPDATE %s SET status = 'sent', attempts = attempts + 1, last_error = NULL, sent_at = now() WHERE id = $1`, o.table)
+
+	failed := fmt.Sprintf(`
+	UPDATE %s SET
+		status = CASE WHEN attempts + 1 >= $3 THEN 'dead' ELSE 'pending' END,
+		attempts = attempts + 1,
+		last_error = $2,
+		available_at = now() + make_interval(secs => $4)
+	WHERE id = $1`, o.table)
+
+	var published int
+	err := o.pool.WithTransaction(ctx, func(ctx context.Context) error {
+		rows, err := o.pool.Query(ctx, claim, o.config.batchSize)
+		if err != nil {
+			return err
+		}
+
+		var msgs []Message
+		for rows.Next() {
+			var msg Message
+			if err := rows.Scan(&msg.ID, &msg.AggregateKey, &msg.Topic, &msg.Payload, &msg.Attempts, &msg.CreatedAt); err != nil {
+				rows.Close()
+				return err
+			}
+			msgs = append(msgs, msg)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, msg := range msgs {
+			published++
+			if errPublish := publisher.Publish(ctx, msg); errPublish != nil {
+				delay := o.config.backoff(msg.Attempts + 1)
+				if _, err := o.pool.Exec(ctx, failed, msg.ID, errPublish.Error(), o.config.maxAttempts, delay.Seconds()); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if _, err := o.pool.Exec(ctx, sent, msg.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return published, nil
+}
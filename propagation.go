@@ -0,0 +1,57 @@
+package pgxtxpool
+
+// Propagation decide how a transaction begin
+// when context may already carry an active transaction
+type Propagation int
+
+const (
+	// PropagationNested will create a savepoint if context carry an active transaction
+	// otherwise begin a new transaction, this is default behaviour of BeginTX
+	PropagationNested Propagation = iota
+
+	// PropagationRequired will join an active transaction if context carry one
+	// otherwise begin a new transaction
+	PropagationRequired
+
+	// PropagationRequiresNew will always begin a new transaction,
+	// active transaction in context is suspended until returned context is committed or rolled back
+	PropagationRequiresNew
+
+	// PropagationSupports will join an active transaction if context carry one
+	// otherwise run without transaction
+	PropagationSupports
+
+	// PropagationMandatory will join an active transaction if context carry one
+	// otherwise return ErrTxPoolTransactionRequired
+	PropagationMandatory
+
+	// PropagationNever will run without transaction
+	// and return ErrTxPoolTransactionExists if context carry an active transaction
+	PropagationNever
+
+	// PropagationNotSupported will always run without transaction,
+	// active transaction in context is suspended until returned context is committed or rolled back
+	PropagationNotSupported
+)
+
+// String will return name of propagation
+func (p Propagation) String() string {
+	switch p {
+	case PropagationNested:
+		return "NESTED"
+	case PropagationRequired:
+		return "REQUIRED"
+	case PropagationRequiresNew:
+		return "REQUIRES_NEW"
+	case PropagationSupports:
+		return "SUPPORTS"
+	case PropagationMandatory:
+		return "MANDATORY"
+	case PropagationNever:
+		return "NEVER"
+	case PropagationNotSupported:
+		return "NOT_SUPPORTED"
+	default:
+		return "UNKNOWN"
+	}
+}
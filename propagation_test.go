@@ -0,0 +1,212 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestBeginTXWithPropagation(t *testing.T) {
+	cases := []struct {
+		name         string
+		propagation  Propagation
+		withOuter    bool
+		expErr       error
+		expNewTx     bool
+		expSavepoint bool
+		expJoin      bool
+	}{
+		{name: "NESTED with outer", propagation: PropagationNested, withOuter: true, expSavepoint: true},
+		{name: "NESTED without outer", propagation: PropagationNested, expNewTx: true},
+		{name: "REQUIRED with outer", propagation: PropagationRequired, withOuter: true, expJoin: true},
+		{name: "REQUIRED without outer", propagation: PropagationRequired, expNewTx: true},
+		{name: "REQUIRES_NEW with outer", propagation: PropagationRequiresNew, withOuter: true, expNewTx: true},
+		{name: "REQUIRES_NEW without outer", propagation: PropagationRequiresNew, expNewTx: true},
+		{name: "SUPPORTS with outer", propagation: PropagationSupports, withOuter: true, expJoin: true},
+		{name: "SUPPORTS without outer", propagation: PropagationSupports},
+		{name: "MANDATORY with outer", propagation: PropagationMandatory, withOuter: true, expJoin: true},
+		{name: "MANDATORY without outer", propagation: PropagationMandatory, expErr: ErrTxPoolTransactionRequired},
+		{name: "NEVER with outer", propagation: PropagationNever, withOuter: true, expErr: ErrTxPoolTransactionExists},
+		{name: "NEVER without outer", propagation: PropagationNever},
+		{name: "NOT_SUPPORTED with outer", propagation: PropagationNotSupported, withOuter: true},
+		{name: "NOT_SUPPORTED without outer", propagation: PropagationNotSupported},
+		{name: "UNKNOWN", propagation: Propagation(99), expErr: ErrTxPoolInvalidPropagation},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p, begun := newFakePool()
+
+			ctx := context.Background()
+			var outer *fakeTx
+			if c.withOuter {
+				ctx, outer = newFakeTXContext(p)
+			}
+
+			innerCTX, err := p.BeginTXWithPropagation(ctx, c.propagation, pgx.TxOptions{})
+			if !errors.Is(err, c.expErr) {
+				t.Logf("expected error %v, got %v", c.expErr, err)
+				t.FailNow()
+			}
+			if err != nil {
+				return
+			}
+
+			if c.expNewTx != (len(*begun) == 1) {
+				t.Logf("expected new transaction %v, got %d transaction", c.expNewTx, len(*begun))
+				t.FailNow()
+			}
+
+			if c.withOuter && c.expSavepoint != outer.called("Begin") {
+				t.Logf("expected savepoint %v", c.expSavepoint)
+				t.FailNow()
+			}
+
+			// query with inner context should be routed to
+			// outer transaction when join, new transaction when begun
+			// and pgxpool when run without transaction (nil pool will panic)
+			runWithoutTx := !c.expNewTx && !c.expSavepoint && !c.expJoin
+			func() {
+				defer func() {
+					if r := recover(); (r != nil) != runWithoutTx {
+						t.Logf("expected run without transaction %v, got panic %v", runWithoutTx, r)
+						t.FailNow()
+					}
+				}()
+				p.Exec(innerCTX, "SELECT 1")
+			}()
+			if c.withOuter && c.expJoin != outer.called("Exec") {
+				t.Logf("expected join outer transaction %v", c.expJoin)
+				t.FailNow()
+			}
+
+			if err := p.CommitTX(innerCTX); err != nil {
+				t.Log(err)
+				t.FailNow()
+			}
+
+			if c.withOuter && outer.called("Commit") {
+				t.Log("commit inner context should not commit outer transaction")
+				t.FailNow()
+			}
+
+			if c.expNewTx && !(*begun)[0].called("Commit") {
+				t.Log("new transaction should be committed")
+				t.FailNow()
+			}
+		})
+	}
+}
+
+func TestRollbackOnly(t *testing.T) {
+	t.Run("should rollback owner transaction when participant rolled back", func(t *testing.T) {
+		p, begun := newFakePool()
+
+		outerCTX, err := p.BeginTXWithPropagation(context.Background(), PropagationRequired, pgx.TxOptions{})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		innerCTX, err := p.BeginTXWithPropagation(outerCTX, PropagationRequired, pgx.TxOptions{})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if err := p.RollbackTX(innerCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		tx := (*begun)[0]
+		if tx.called("Rollback") {
+			t.Log("participant should not rollback owner transaction directly")
+			t.FailNow()
+		}
+
+		if err := p.CommitTX(outerCTX); !errors.Is(err, ErrTxPoolRollbackOnly) {
+			t.Logf("expected error %v, got %v", ErrTxPoolRollbackOnly, err)
+			t.FailNow()
+		}
+
+		if !tx.called("Rollback") || tx.called("Commit") {
+			t.Log("owner transaction should be rolled back on commit")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should remove participants when owner is committed", func(t *testing.T) {
+		p, begun := newFakePool()
+
+		outerCTX, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		savepointCTX, err := p.BeginTX(outerCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		joinedCTX, err := p.BeginTXWithPropagation(outerCTX, PropagationRequired, pgx.TxOptions{})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if err := p.CommitTX(outerCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if len(p.ActiveTransactions()) != 0 {
+			t.Logf("participants should be removed along with owner, got %v", p.ActiveTransactions())
+			t.FailNow()
+		}
+		for _, ctx := range []context.Context{savepointCTX, joinedCTX} {
+			if err := p.CommitTX(ctx); !errors.Is(err, ErrTxPoolNotFound) {
+				t.Logf("expected error %v, got %v", ErrTxPoolNotFound, err)
+				t.FailNow()
+			}
+		}
+		if (*begun)[0].children[0].called("Commit") {
+			t.Log("savepoint should not be released after owner is committed")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should mark owner when error returned from joined closure", func(t *testing.T) {
+		p, begun := newFakePool()
+		expErr := errors.New("something went wrong")
+
+		err := p.WithTransaction(context.Background(), func(ctx context.Context) error {
+			// error from joined closure is ignored by the owner
+			_ = p.WithTransactionPropagation(ctx, PropagationMandatory, pgx.TxOptions{}, func(ctx context.Context) error {
+				return expErr
+			})
+			return nil
+		})
+		if !errors.Is(err, ErrTxPoolRollbackOnly) {
+			t.Logf("expected error %v, got %v", ErrTxPoolRollbackOnly, err)
+			t.FailNow()
+		}
+
+		if !(*begun)[0].called("Rollback") {
+			t.Log("owner transaction should be rolled back")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should return error when joined options mismatch", func(t *testing.T) {
+		p, _ := newFakePool()
+		outerCTX, _ := newFakeTXContext(p)
+
+		_, err := p.BeginTXWithPropagation(outerCTX, PropagationRequired, pgx.TxOptions{IsoLevel: pgx.Serializable})
+		if !errors.Is(err, ErrTxPoolOptionsMismatch) {
+			t.Logf("expected error %v, got %v", ErrTxPoolOptionsMismatch, err)
+			t.FailNow()
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package pgxtxpool
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Querier is a set of query methods shared by Pool and pgx.Tx
+// it is compatible with DBTX interface generated by sqlc for pgx/v5,
+// so generated Queries can use Pool and join the transaction carried in the context
+// ex: queries := db.New(pool)
+type Querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+var (
+	_ Querier = (*Pool)(nil)
+	_ Querier = (pgx.Tx)(nil)
+)
@@ -0,0 +1,71 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// code below is what sqlc generate for pgx/v5 (db.go and query.sql.go)
+// it is kept as is to make sure Pool can be used as its DBTX
+
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...interface{}) pgx.Row
+}
+
+func NewQueries(db DBTX) *Queries {
+	return &Queries{db: db}
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const createUser = `-- name: CreateUser :exec
+INSERT INTO users (id, name, balance) VALUES ($1, $2, $3)
+`
+
+func (q *Queries) CreateUser(ctx context.Context, id string, name string, balance float64) error {
+	_, err := q.db.Exec(ctx, createUser, id, name, balance)
+	return err
+}
+
+const getUserName = `-- name: GetUserName :one
+SELECT name FROM users WHERE id = $1
+`
+
+func (q *Queries) GetUserName(ctx context.Context, id string) pgx.Row {
+	return q.db.QueryRow(ctx, getUserName, id)
+}
+
+func TestQuerierWithSQLC(t *testing.T) {
+	p, begun := newFakePool()
+	var db Querier = p
+	queries := NewQueries(db)
+
+	err := p.WithTransaction(context.Background(), func(ctx context.Context) error {
+		if err := queries.CreateUser(ctx, "USR001", "John Doe", 1000); err != nil {
+			return err
+		}
+		var name string
+		if err := queries.GetUserName(ctx, "USR001").Scan(&name); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	tx := (*begun)[0]
+	if !tx.called("Exec") || !tx.called("QueryRow") || !tx.called("Commit") {
+		t.Log("generated queries should join the transaction in context")
+		t.FailNow()
+	}
+}
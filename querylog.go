@@ -0,0 +1,147 @@
+package pgxtxpool
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// default level of query log
+const defaultQueryLogLevel = slog.LevelDebug
+
+// queryLogKey is context key to carry start of a query from TraceQueryStart to TraceQueryEnd
+type queryLogKey struct{}
+
+// queryLogStart is start of a query that logged by queryLogger
+type queryLogStart struct {
+	startedAt time.Time
+	data      pgx.TraceQueryStartData
+}
+
+type queryLogConfig struct {
+	level         slog.Level
+	slowThreshold time.Duration
+	logArgs       bool
+	redact        func(index int, arg any) any
+}
+
+// QueryLogOption is a function that can be used to configure WithQueryLogger
+type QueryLogOption func(*queryLogConfig)
+
+// WithQueryLogLevel will set level of query log
+// default is debug, failed query is always logged as error
+func WithQueryLogLevel(level slog.Level) QueryLogOption {
+	return func(c *queryLogConfig) {
+		c.level = level
+	}
+}
+
+// WithSlowQueryThreshold will log query that take longer than threshold as warning
+// regardless of level set by WithQueryLogLevel
+func WithSlowQueryThreshold(threshold time.Duration) QueryLogOption {
+	return func(c *queryLogConfig) {
+		c.slowThreshold = threshold
+	}
+}
+
+// WithQueryLogArgs will log arguments of query
+// by default only number of arguments is logged
+// each argument is passed to redact before logged, so sensitive value can be hidden
+// ex: func(index int, arg any) any { return "[REDACTED]" }
+// redact can be nil to log arguments as is
+func WithQueryLogArgs(redact func(index int, arg any) any) QueryLogOption {
+	return func(c *queryLogConfig) {
+		c.logArgs = true
+		c.redact = redact
+	}
+}
+
+// WithQueryLogger will log every query executed by the pool using logger
+// each log carry transaction id from the context, SQL, number of arguments,
+// duration, rows affected and error
+// ex: WithQueryLogger(slog.Default(), WithSlowQueryThreshold(time.Second))
+func WithQueryLogger(logger *slog.Logger, opts ...QueryLogOption) Option {
+	return func(c *config) {
+		if logger == nil {
+			c.invalid("query logger is nil")
+			return
+		}
+		WithQueryTracer(newQueryLogger(logger, opts...))(c)
+	}
+}
+
+// queryLogger is a pgx.QueryTracer that log queries using slog
+type queryLogger struct {
+	logger *slog.Logger
+	config queryLogConfig
+}
+
+// newQueryLogger will create a queryLogger
+func newQueryLogger(logger *slog.Logger, opts ...QueryLogOption) *queryLogger {
+	config := queryLogConfig{level: defaultQueryLogLevel}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return &queryLogger{logger: logger, config: config}
+}
+
+// TraceQueryStart will save start of the query into context
+func (l *queryLogger) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryLogKey{}, queryLogStart{startedAt: time.Now(), data: data})
+}
+
+// TraceQueryEnd will log the query
+// level is error when query failed, warning when query is slow, otherwise configured level
+func (l *queryLogger) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryLogKey{}).(queryLogStart)
+	if !ok {
+		return
+	}
+	duration := time.Since(start.startedAt)
+
+	level := l.config.level
+	switch {
+	case data.Err != nil:
+		level = slog.LevelError
+	case l.config.slowThreshold > 0 && duration >= l.config.slowThreshold:
+		level = slog.LevelWarn
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 7)
+	if txID, ok := TxIDFromContext(ctx); ok {
+		attrs = append(attrs, slog.String("tx_id", string(txID)))
+	}
+	attrs = append(attrs,
+		slog.String("sql", start.data.SQL),
+		slog.Int("args_count", len(start.data.Args)),
+	)
+	if l.config.logArgs {
+		attrs = append(attrs, slog.Any("args", l.args(start.data.Args)))
+	}
+	attrs = append(attrs,
+		slog.Duration("duration", duration),
+		slog.Int64("rows_affected", data.CommandTag.RowsAffected()),
+	)
+	if data.Err != nil {
+		attrs = append(attrs, slog.Any("error", data.Err))
+	}
+
+	l.logger.LogAttrs(ctx, level, "query", attrs...)
+}
+
+// args will return arguments of query after redacted
+func (l *queryLogger) args(args []any) []any {
+	if l.config.redact == nil {
+		return args
+	}
+	redacted := make([]any, len(args))
+	for i, arg := range args {
+		redacted[i] = l.config.redact(i, arg)
+	}
+	return redacted
+}
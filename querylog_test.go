@@ -0,0 +1,144 @@
+package pgxtxpool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// newTestQueryLogger will create a queryLogger that write json log into a buffer
+func newTestQueryLogger(level slog.Level, opts ...QueryLogOption) (*queryLogger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: level}))
+	return newQueryLogger(logger, opts...), buf
+}
+
+// traceQuery will run a query through the tracer then return decoded log
+func traceQuery(t *testing.T, l *queryLogger, buf *bytes.Buffer, ctx context.Context, start pgx.TraceQueryStartData, end pgx.TraceQueryEndData) map[string]any {
+	ctx = l.TraceQueryStart(ctx, nil, start)
+	l.TraceQueryEnd(ctx, nil, end)
+
+	if buf.Len() == 0 {
+		return nil
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	return entry
+}
+
+func TestQueryLogger(t *testing.T) {
+	t.Run("should log query with transaction id", func(t *testing.T) {
+		l, buf := newTestQueryLogger(slog.LevelDebug)
+		ctx := (&Pool{}).ContextWithTxID(context.Background(), TxID("tx-1"))
+
+		entry := traceQuery(t, l, buf, ctx,
+			pgx.TraceQueryStartData{SQL: "UPDATE users SET balance = $1", Args: []any{100}},
+			pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("UPDATE 2")},
+		)
+
+		expected := map[string]any{
+			"level":         "DEBUG",
+			"msg":           "query",
+			"tx_id":         "tx-1",
+			"sql":           "UPDATE users SET balance = $1",
+			"args_count":    float64(1),
+			"rows_affected": float64(2),
+		}
+		for key, value := range expected {
+			if entry[key] != value {
+				t.Logf("expected %s to be %v, got %v", key, value, entry[key])
+				t.FailNow()
+			}
+		}
+		if _, ok := entry["args"]; ok {
+			t.Log("arguments should not be logged by default")
+			t.FailNow()
+		}
+		if _, ok := entry["duration"]; !ok {
+			t.Log("duration should be logged")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should log failed query as error", func(t *testing.T) {
+		l, buf := newTestQueryLogger(slog.LevelInfo)
+
+		entry := traceQuery(t, l, buf, context.Background(),
+			pgx.TraceQueryStartData{SQL: "SELECT 1"},
+			pgx.TraceQueryEndData{Err: errors.New("relation does not exist")},
+		)
+
+		if entry["level"] != "ERROR" || entry["error"] != "relation does not exist" {
+			t.Logf("unexpected log %v", entry)
+			t.FailNow()
+		}
+		if _, ok := entry["tx_id"]; ok {
+			t.Log("query without transaction should not have tx id")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should log slow query as warning", func(t *testing.T) {
+		l, buf := newTestQueryLogger(slog.LevelWarn, WithSlowQueryThreshold(time.Nanosecond))
+
+		entry := traceQuery(t, l, buf, context.Background(),
+			pgx.TraceQueryStartData{SQL: "SELECT pg_sleep(1)"},
+			pgx.TraceQueryEndData{},
+		)
+
+		if entry["level"] != "WARN" {
+			t.Logf("expected slow query logged as warning, got %v", entry)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should not log query below handler level", func(t *testing.T) {
+		l, buf := newTestQueryLogger(slog.LevelInfo)
+
+		entry := traceQuery(t, l, buf, context.Background(), pgx.TraceQueryStartData{SQL: "SELECT 1"}, pgx.TraceQueryEndData{})
+		if entry != nil {
+			t.Logf("expected no log, got %v", entry)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should log query using configured level", func(t *testing.T) {
+		l, buf := newTestQueryLogger(slog.LevelInfo, WithQueryLogLevel(slog.LevelInfo))
+
+		entry := traceQuery(t, l, buf, context.Background(), pgx.TraceQueryStartData{SQL: "SELECT 1"}, pgx.TraceQueryEndData{})
+		if entry["level"] != "INFO" {
+			t.Logf("expected query logged as info, got %v", entry)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should log redacted arguments", func(t *testing.T) {
+		l, buf := newTestQueryLogger(slog.LevelDebug, WithQueryLogArgs(func(index int, arg any) any {
+			if index == 1 {
+				return "[REDACTED]"
+			}
+			return arg
+		}))
+
+		entry := traceQuery(t, l, buf, context.Background(),
+			pgx.TraceQueryStartData{SQL: "INSERT INTO users (id, password) VALUES ($1, $2)", Args: []any{"USR01", "secret"}},
+			pgx.TraceQueryEndData{},
+		)
+
+		args, _ := entry["args"].([]any)
+		if len(args) != 2 || args[0] != "USR01" || args[1] != "[REDACTED]" {
+			t.Logf("unexpected arguments %v", entry["args"])
+			t.FailNow()
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package pgxtxpool
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// reaper is a background goroutine that rollback transaction
+// that still in the pool longer than max transaction lifetime
+type reaper struct {
+	maxTxLifetime time.Duration
+	onTxReaped    func(txID TxID, age time.Duration)
+	stop          chan struct{}
+	done          chan struct{}
+	stopOnce      sync.Once
+}
+
+// startReaper will start the reaper
+// it scan the pool every half of max transaction lifetime
+func (p *Pool) startReaper(maxTxLifetime time.Duration, onTxReaped func(txID TxID, age time.Duration)) {
+	if onTxReaped == nil {
+		onTxReaped = logTxReaped
+	}
+	p.reaper = &reaper{
+		maxTxLifetime: maxTxLifetime,
+		onTxReaped:    onTxReaped,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+
+	interval := maxTxLifetime / 2
+	if interval <= 0 {
+		interval = maxTxLifetime
+	}
+
+	go func() {
+		defer close(p.reaper.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.reaper.stop:
+				return
+			case <-ticker.C:
+				p.reapTX()
+			}
+		}
+	}()
+}
+
+// stopReaper will stop the reaper and wait until it exit
+// it is safe to call multiple times or when reaper is not started
+func (p *Pool) stopReaper() {
+	if p.reaper == nil {
+		return
+	}
+	p.reaper.stopOnce.Do(func() {
+		close(p.reaper.stop)
+	})
+	<-p.reaper.done
+}
+
+// reapTX will rollback and remove every transaction
+// that started longer than max transaction lifetime
+// only transaction that own a connection is reaped,
+// its savepoints and joined transactions are removed along with it
+// scope without transaction is removed without rollback
+func (p *Pool) reapTX() {
+	p.txpool.Range(func(key, value any) bool {
+		txID, conn := key.(TxID), value.(*txConn)
+		if conn.kind != TxKindTransaction && conn.kind != TxKindNone {
+			return true
+		}
+		age := time.Since(conn.startedAt)
+		if age <= p.reaper.maxTxLifetime {
+			return true
+		}
+		if conn.kind == TxKindNone {
+			if p.dropTX(txID, conn, ErrTxPoolLifetimeExceeded) {
+				p.reaper.onTxReaped(txID, age)
+			}
+			return true
+		}
+		if p.abortTX(txID, conn, ErrTxPoolLifetimeExceeded) {
+			p.stats.recordReap(conn)
+			p.reaper.onTxReaped(txID, age)
+		}
+		return true
+	})
+}
+
+// logTxReaped will log reaped transaction as a warning using slog default logger
+func logTxReaped(txID TxID, age time.Duration) {
+	slog.Warn("pgxtxpool: transaction reaped", "tx_id", txID, "age", age)
+}
@@ -0,0 +1,197 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestReaper(t *testing.T) {
+	t.Run("should reap only transaction older than max lifetime", func(t *testing.T) {
+		p, begun := newFakePool()
+
+		var reaped []TxID
+		p.reaper = &reaper{
+			maxTxLifetime: time.Minute,
+			onTxReaped: func(txID TxID, age time.Duration) {
+				reaped = append(reaped, txID)
+			},
+		}
+
+		oldCTX, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		newCTX, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		// make first transaction older than max lifetime
+		oldConn, _ := p.getTXConnFromContext(oldCTX)
+		oldConn.startedAt = time.Now().Add(-2 * time.Minute)
+
+		p.reapTX()
+
+		if len(reaped) != 1 || reaped[0] != txIDOf(p, oldCTX) {
+			t.Logf("expected only old transaction reaped, got %v", reaped)
+			t.FailNow()
+		}
+
+		if !(*begun)[0].called("Rollback") || (*begun)[1].called("Rollback") {
+			t.Log("only old transaction should be rolled back")
+			t.FailNow()
+		}
+
+		if err := p.CommitTX(oldCTX); !errors.Is(err, ErrTxPoolLifetimeExceeded) {
+			t.Logf("expected error %v, got %v", ErrTxPoolLifetimeExceeded, err)
+			t.FailNow()
+		}
+
+		if err := p.CommitTX(newCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should reap only transaction that own the connection", func(t *testing.T) {
+		p, begun := newFakePool()
+		hooks := &recordHooks{}
+		p.hooks = multiHooks{hooks}
+		p.reaper = &reaper{maxTxLifetime: time.Minute, onTxReaped: func(txID TxID, age time.Duration) {}}
+
+		txCTX, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		savepointCTX, err := p.BeginTX(txCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		var joined []context.Context
+		for range 5 {
+			joinedCTX, err := p.BeginTXWithPropagation(txCTX, PropagationRequired, pgx.TxOptions{})
+			if err != nil {
+				t.Log(err)
+				t.FailNow()
+			}
+			joined = append(joined, joinedCTX)
+		}
+
+		// every entry is older than max lifetime
+		for _, info := range p.ActiveTransactions() {
+			conn, _ := p.getTXConn(info.TxID)
+			conn.startedAt = time.Now().Add(-2 * time.Minute)
+		}
+
+		p.reapTX()
+
+		tx := (*begun)[0]
+		tx.mx.Lock()
+		rollbacks := 0
+		for _, call := range tx.calls {
+			if call == "Rollback" {
+				rollbacks++
+			}
+		}
+		tx.mx.Unlock()
+		if rollbacks != 1 || tx.children[0].called("Rollback") {
+			t.Logf("transaction should be rolled back once, got %d", rollbacks)
+			t.FailNow()
+		}
+
+		calls := hooks.recorded()
+		if len(calls) != 2 || calls[1] != "AfterRollback" {
+			t.Logf("expected hooks [OnBegin AfterRollback], got %v", calls)
+			t.FailNow()
+		}
+		if len(p.ActiveTransactions()) != 0 || p.TxStats().Reaped != 1 {
+			t.Log("savepoint and joined transaction should be removed along with their owner")
+			t.FailNow()
+		}
+
+		for _, ctx := range append(joined, savepointCTX) {
+			if err := p.CommitTX(ctx); !errors.Is(err, ErrTxPoolLifetimeExceeded) {
+				t.Logf("expected error %v, got %v", ErrTxPoolLifetimeExceeded, err)
+				t.FailNow()
+			}
+		}
+	})
+
+	t.Run("should remove scope without transaction older than max lifetime", func(t *testing.T) {
+		p, _ := newFakePool()
+		var reaped []TxID
+		p.reaper = &reaper{maxTxLifetime: time.Minute, onTxReaped: func(txID TxID, age time.Duration) {
+			reaped = append(reaped, txID)
+		}}
+
+		scopeCTX, err := p.BeginTXWithPropagation(context.Background(), PropagationNotSupported, pgx.TxOptions{})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		conn, _ := p.getTXConnFromContext(scopeCTX)
+		conn.startedAt = time.Now().Add(-2 * time.Minute)
+
+		p.reapTX()
+
+		if len(reaped) != 1 || len(p.ActiveTransactions()) != 0 || p.TxStats().Reaped != 0 {
+			t.Logf("leaked scope should be removed without counting as reaped transaction, got %v", reaped)
+			t.FailNow()
+		}
+		if err := p.CommitTX(scopeCTX); !errors.Is(err, ErrTxPoolLifetimeExceeded) {
+			t.Logf("expected error %v, got %v", ErrTxPoolLifetimeExceeded, err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should reap in background until stopped", func(t *testing.T) {
+		p, begun := newFakePool()
+
+		var mx sync.Mutex
+		var reaped []TxID
+		p.startReaper(10*time.Millisecond, func(txID TxID, age time.Duration) {
+			mx.Lock()
+			defer mx.Unlock()
+			reaped = append(reaped, txID)
+		})
+
+		txCTX, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		tx := (*begun)[0]
+		waitUntil(t, func() bool { return tx.called("Rollback") })
+
+		if err := p.VerifyTX(txCTX); err != nil {
+			t.Log("reaped transaction should be removed from pool")
+			t.FailNow()
+		}
+
+		p.stopReaper()
+		// stop twice should be safe
+		p.stopReaper()
+
+		mx.Lock()
+		defer mx.Unlock()
+		if len(reaped) != 1 {
+			t.Logf("expected 1 reaped transaction, got %d", len(reaped))
+			t.FailNow()
+		}
+	})
+
+	t.Run("should be safe to stop when reaper is not started", func(t *testing.T) {
+		p, _ := newFakePool()
+		p.stopReaper()
+	})
+}
@@ -0,0 +1,166 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseDelay   = 10 * time.Millisecond
+	defaultRetryMaxDelay    = time.Second
+)
+
+// retryable SQLSTATE codes
+// 40001: serialization_failure
+// 40P01: deadlock_detected
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+type retryConfig struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	txOptions   pgx.TxOptions
+	retryable   []func(err error) bool
+	notify      func(ctx context.Context, attempt int, err error)
+}
+
+// isRetryable will check error against default classifier
+// and every classifier that added using WithRetryableError
+func (c *retryConfig) isRetryable(err error) bool {
+	if IsRetryableError(err) {
+		return true
+	}
+	for _, retryable := range c.retryable {
+		if retryable(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff will return delay before next attempt
+// it use exponential backoff with full jitter
+// ex: attempt 3 with base delay 10ms will wait between 0 and 40ms
+func (c *retryConfig) backoff(attempt int) time.Duration {
+	delay := c.baseDelay << (attempt - 1)
+	if delay <= 0 || delay > c.maxDelay {
+		delay = c.maxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay + 1)
+}
+
+// RetryOption is a function that can be used to configure WithTransactionRetry
+type RetryOption func(*retryConfig)
+
+// WithRetryMaxAttempts will set maximum number of attempts including the first one
+// default is 3 attempts
+func WithRetryMaxAttempts(maxAttempts int) RetryOption {
+	return func(c *retryConfig) {
+		c.maxAttempts = maxAttempts
+	}
+}
+
+// WithRetryBackoff will set base and maximum delay between attempts
+// default is 10ms base delay and 1s maximum delay
+func WithRetryBackoff(baseDelay, maxDelay time.Duration) RetryOption {
+	return func(c *retryConfig) {
+		c.baseDelay = baseDelay
+		c.maxDelay = maxDelay
+	}
+}
+
+// WithRetryTxOptions will set options that used to begin transaction on every attempt
+// ex: pgx.TxOptions{IsoLevel: pgx.Serializable}
+func WithRetryTxOptions(txOptions pgx.TxOptions) RetryOption {
+	return func(c *retryConfig) {
+		c.txOptions = txOptions
+	}
+}
+
+// WithRetryableError will add a classifier to decide whether an error is retryable
+// it extend default classifier (IsRetryableError) instead of replacing it
+func WithRetryableError(retryable func(err error) bool) RetryOption {
+	return func(c *retryConfig) {
+		c.retryable = append(c.retryable, retryable)
+	}
+}
+
+// WithRetryNotify will set a function that called every time an attempt failed and will be retried
+// attempt is number of failed attempt, start from 1
+// use this function to report retry counts
+func WithRetryNotify(notify func(ctx context.Context, attempt int, err error)) RetryOption {
+	return func(c *retryConfig) {
+		c.notify = notify
+	}
+}
+
+// IsRetryableError will check whether an error is caused by
+// serialization failure (40001) or deadlock (40P01)
+// and the whole transaction can be run again from the top
+func IsRetryableError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected
+}
+
+// WithTransactionRetry will run fn inside a transaction like WithTransaction
+// if fn or commit failed with retryable error then transaction will be rolled back
+// and fn will be run again inside a new transaction with a fresh transaction id
+// until it succeed or maximum attempts is reached
+// when maximum attempts is reached it will return ErrTxPoolRetryExhausted along with the last error
+// if context already carry an active transaction then fn will only run once inside a savepoint,
+// because retryable error abort the outer transaction too, so it is the outer transaction that should be retried
+func (p *Pool) WithTransactionRetry(ctx context.Context, fn func(ctx context.Context) error, opts ...RetryOption) error {
+	config := retryConfig{
+		maxAttempts: defaultRetryMaxAttempts,
+		baseDelay:   defaultRetryBaseDelay,
+		maxDelay:    defaultRetryMaxDelay,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if _, ok := p.getTXFromContext(ctx); ok {
+		return p.withTransaction(ctx, PropagationNested, config.txOptions, fn)
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = p.withTransaction(ctx, PropagationNested, config.txOptions, fn)
+		if err == nil || !config.isRetryable(err) {
+			return err
+		}
+
+		if attempt >= config.maxAttempts {
+			return fmt.Errorf("%w after %d attempts: %w", ErrTxPoolRetryExhausted, attempt, err)
+		}
+
+		p.stats.recordRetry()
+		if config.notify != nil {
+			config.notify(ctx, attempt, err)
+		}
+
+		timer := time.NewTimer(config.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
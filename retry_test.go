@@ -0,0 +1,195 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{name: "serialization failure", err: &pgconn.PgError{Code: "40001"}, retryable: true},
+		{name: "deadlock detected", err: &pgconn.PgError{Code: "40P01"}, retryable: true},
+		{name: "wrapped serialization failure", err: fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: "40001"}), retryable: true},
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}, retryable: false},
+		{name: "non postgres error", err: errors.New("something went wrong"), retryable: false},
+		{name: "nil error", err: nil, retryable: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if IsRetryableError(c.err) != c.retryable {
+				t.Logf("expected retryable %v for %v", c.retryable, c.err)
+				t.FailNow()
+			}
+		})
+	}
+}
+
+func TestWithTransactionRetry(t *testing.T) {
+	serializationFailure := &pgconn.PgError{Code: "40001"}
+	noBackoff := WithRetryBackoff(0, 0)
+
+	t.Run("should retry with fresh transaction until succeed", func(t *testing.T) {
+		p, begun := newFakePool()
+
+		var txIDs []TxID
+		var notified []int
+		err := p.WithTransactionRetry(context.Background(), func(ctx context.Context) error {
+			txIDs = append(txIDs, txIDOf(p, ctx))
+			if len(txIDs) < 3 {
+				return serializationFailure
+			}
+			return nil
+		},
+			noBackoff,
+			WithRetryMaxAttempts(5),
+			WithRetryNotify(func(ctx context.Context, attempt int, err error) {
+				notified = append(notified, attempt)
+			}),
+		)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if len(*begun) != 3 {
+			t.Logf("expected 3 transactions, got %d", len(*begun))
+			t.FailNow()
+		}
+		for i, tx := range *begun {
+			if i < 2 && !tx.called("Rollback") {
+				t.Logf("attempt %d should be rolled back", i+1)
+				t.FailNow()
+			}
+		}
+		if !(*begun)[2].called("Commit") {
+			t.Log("last attempt should be committed")
+			t.FailNow()
+		}
+
+		if txIDs[0] == txIDs[1] || txIDs[1] == txIDs[2] {
+			t.Log("every attempt should have fresh transaction id")
+			t.FailNow()
+		}
+
+		if len(notified) != 2 || notified[0] != 1 || notified[1] != 2 {
+			t.Logf("expected notified attempts [1 2], got %v", notified)
+			t.FailNow()
+		}
+
+		if retried := p.TxStats().Retried; retried != 2 {
+			t.Logf("expected 2 retries in stats, got %d", retried)
+			t.FailNow()
+		}
+
+		// make sure no transaction left in pool
+		for _, txID := range txIDs {
+			if _, ok := p.getTXConn(txID); ok {
+				t.Logf("transaction %s still exists in pool", txID)
+				t.FailNow()
+			}
+		}
+	})
+
+	t.Run("should return exhausted error after max attempts", func(t *testing.T) {
+		p, begun := newFakePool()
+
+		err := p.WithTransactionRetry(context.Background(), func(ctx context.Context) error {
+			return serializationFailure
+		}, noBackoff, WithRetryMaxAttempts(2))
+		if !errors.Is(err, ErrTxPoolRetryExhausted) || !errors.Is(err, serializationFailure) {
+			t.Logf("expected error %v, got %v", ErrTxPoolRetryExhausted, err)
+			t.FailNow()
+		}
+
+		if len(*begun) != 2 {
+			t.Logf("expected 2 transactions, got %d", len(*begun))
+			t.FailNow()
+		}
+	})
+
+	t.Run("should not retry non retryable error", func(t *testing.T) {
+		p, begun := newFakePool()
+		expErr := errors.New("something went wrong")
+
+		err := p.WithTransactionRetry(context.Background(), func(ctx context.Context) error {
+			return expErr
+		}, noBackoff)
+		if !errors.Is(err, expErr) || errors.Is(err, ErrTxPoolRetryExhausted) {
+			t.Logf("expected error %v, got %v", expErr, err)
+			t.FailNow()
+		}
+
+		if len(*begun) != 1 {
+			t.Logf("expected 1 transaction, got %d", len(*begun))
+			t.FailNow()
+		}
+	})
+
+	t.Run("should retry error from custom classifier", func(t *testing.T) {
+		p, begun := newFakePool()
+		expErr := errors.New("lock not available")
+
+		err := p.WithTransactionRetry(context.Background(), func(ctx context.Context) error {
+			return expErr
+		},
+			noBackoff,
+			WithRetryMaxAttempts(3),
+			WithRetryableError(func(err error) bool { return errors.Is(err, expErr) }),
+		)
+		if !errors.Is(err, ErrTxPoolRetryExhausted) {
+			t.Logf("expected error %v, got %v", ErrTxPoolRetryExhausted, err)
+			t.FailNow()
+		}
+
+		if len(*begun) != 3 {
+			t.Logf("expected 3 transactions, got %d", len(*begun))
+			t.FailNow()
+		}
+	})
+
+	t.Run("should stop retrying when context is done", func(t *testing.T) {
+		p, _ := newFakePool()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		err := p.WithTransactionRetry(ctx, func(ctx context.Context) error {
+			cancel()
+			return serializationFailure
+		}, WithRetryBackoff(time.Minute, time.Minute))
+		if !errors.Is(err, context.Canceled) {
+			t.Logf("expected error %v, got %v", context.Canceled, err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should begin transaction using tx options", func(t *testing.T) {
+		p, _ := newFakePool()
+		serializable := pgx.TxOptions{IsoLevel: pgx.Serializable}
+
+		var opts pgx.TxOptions
+		err := p.WithTransactionRetry(context.Background(), func(ctx context.Context) error {
+			var err error
+			opts, err = p.TxOptions(ctx)
+			return err
+		}, WithRetryTxOptions(serializable))
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if opts != serializable {
+			t.Logf("expected options %+v, got %+v", serializable, opts)
+			t.FailNow()
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package pgxtxpool
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// shutdownPollInterval is how often Shutdown check whether in-flight transactions are done
+const shutdownPollInterval = 10 * time.Millisecond
+
+// ShutdownReport is the result of Shutdown
+type ShutdownReport struct {
+	// RolledBack is transactions that are rolled back by Shutdown
+	RolledBack []TxInfo
+
+	// Busy is transactions that were running a statement when Shutdown rolled back the remainder,
+	// they are removed from the pool and rolled back by the statement after it return
+	Busy []TxInfo
+}
+
+// Shutdown will gracefully close the pool
+// it stop accepting new transaction, BeginTX that need to begin one return ErrTxPoolClosed,
+// savepoint and joined transaction can still be begun, so in-flight transaction can finish its work
+// then it wait for in-flight transactions until ctx is done,
+// transactions that remain are removed from the pool, CommitTX or RollbackTX of them return ErrTxPoolShutdown,
+// rows that are not closed are closed and the transaction is rolled back,
+// transaction that is running a statement is reported as busy and rolled back after the statement return
+// then it stop the reaper and close all connections in the pool,
+// closing the pool wait until busy transactions release their connection
+// ex: ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (p *Pool) Shutdown(ctx context.Context) (ShutdownReport, error) {
+	if !p.closed.CompareAndSwap(false, true) {
+		return ShutdownReport{}, ErrTxPoolClosed
+	}
+
+	p.drainTX(ctx)
+	report := p.rollbackRemainingTX()
+
+	p.stopReaper()
+	p.Pool.Close()
+	return report, nil
+}
+
+// drainTX will wait until there is no transaction being begun or registered in the pool
+// or ctx is done
+func (p *Pool) drainTX(ctx context.Context) {
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for p.beginning.Load() > 0 || p.hasTX() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// hasTX will report whether any transaction is registered in the pool
+func (p *Pool) hasTX() bool {
+	found := false
+	p.txpool.Range(func(key, value any) bool {
+		found = true
+		return false
+	})
+	return found
+}
+
+// rollbackRemainingTX will remove every transaction that is still in the pool and rollback it
+// transaction that own a connection is rolled back without waiting, because the deadline already passed,
+// its rows that are not closed are closed, transaction that is running a statement
+// is left to the statement to rollback after it return, so pgx.Tx is never used concurrently
+// its savepoints and joined transactions are removed along with it and reported the same way,
+// then scope without transaction is removed
+func (p *Pool) rollbackRemainingTX() ShutdownReport {
+	type entry struct {
+		txID TxID
+		conn *txConn
+	}
+	var owners, others []entry
+	p.txpool.Range(func(key, value any) bool {
+		e := entry{txID: key.(TxID), conn: value.(*txConn)}
+		if e.conn.kind == TxKindTransaction {
+			owners = append(owners, e)
+		} else {
+			others = append(others, e)
+		}
+		return true
+	})
+
+	var report ShutdownReport
+	rolledBack := make(map[*txConn]bool)
+	for _, e := range owners {
+		info := newTxInfo(e.txID, e.conn)
+		if !p.takeAbortedTX(e.txID, e.conn, ErrTxPoolShutdown) {
+			continue
+		}
+		p.stats.recordAbort(e.conn)
+		rolledBack[e.conn] = p.rollbackAbortedTX(e.txID, e.conn)
+		if rolledBack[e.conn] {
+			report.RolledBack = append(report.RolledBack, info)
+		} else {
+			report.Busy = append(report.Busy, info)
+		}
+	}
+
+	// savepoint and joined transaction are dropped when their owner is aborted
+	for _, e := range others {
+		info := newTxInfo(e.txID, e.conn)
+		root := e.conn.root()
+		ownerRolledBack, aborted := rolledBack[root]
+		if !aborted && !p.dropTX(e.txID, e.conn, ErrTxPoolShutdown) {
+			continue
+		}
+		switch {
+		case e.conn.tx == nil:
+		case aborted && !ownerRolledBack:
+			report.Busy = append(report.Busy, info)
+		default:
+			report.RolledBack = append(report.RolledBack, info)
+		}
+	}
+
+	sortTxInfo(report.RolledBack)
+	sortTxInfo(report.Busy)
+	return report
+}
+
+// sortTxInfo will sort transactions from the oldest one
+func sortTxInfo(infos []TxInfo) {
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartedAt.Before(infos[j].StartedAt)
+	})
+}
@@ -0,0 +1,253 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newShutdownPool will create a fake pool on top of pgxpool that never connect
+// so Shutdown can close it
+func newShutdownPool(t *testing.T) (*Pool, *[]*fakeTx) {
+	pool, err := pgxpool.New(context.Background(), "postgres://postgres@127.0.0.1:1/postgres?sslmode=disable")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	p, begun := newFakePool()
+	p.Pool = pool
+	return p, begun
+}
+
+func TestShutdown(t *testing.T) {
+	t.Run("should wait for in-flight transaction", func(t *testing.T) {
+		p, begun := newShutdownPool(t)
+
+		txCTX, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			p.CommitTX(txCTX)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		report, err := p.Shutdown(ctx)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if len(report.RolledBack) != 0 || !(*begun)[0].called("Commit") || (*begun)[0].called("Rollback") {
+			t.Logf("in-flight transaction should be committed, got rolled back %v", report.RolledBack)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should reject new transaction", func(t *testing.T) {
+		p, _ := newShutdownPool(t)
+
+		if _, err := p.Shutdown(context.Background()); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if _, err := p.BeginTX(context.Background()); !errors.Is(err, ErrTxPoolClosed) {
+			t.Logf("expected error %v, got %v", ErrTxPoolClosed, err)
+			t.FailNow()
+		}
+		if _, err := p.Shutdown(context.Background()); !errors.Is(err, ErrTxPoolClosed) {
+			t.Logf("expected error %v, got %v", ErrTxPoolClosed, err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should allow savepoint of in-flight transaction", func(t *testing.T) {
+		p, _ := newShutdownPool(t)
+
+		txCTX, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			p.Shutdown(context.Background())
+		}()
+		waitUntil(t, p.closed.Load)
+
+		savepointCTX, err := p.BeginTX(txCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err := p.CommitTX(savepointCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err := p.CommitTX(txCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		<-done
+	})
+
+	t.Run("should rollback transaction that has rows that are not closed", func(t *testing.T) {
+		p, begun := newShutdownPool(t)
+
+		txCTX, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		rows, err := p.Query(txCTX, "SELECT 1")
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		defer rows.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		type result struct {
+			report ShutdownReport
+			err    error
+		}
+		done := make(chan result, 1)
+		go func() {
+			report, err := p.Shutdown(ctx)
+			done <- result{report: report, err: err}
+		}()
+
+		select {
+		case res := <-done:
+			if res.err != nil || len(res.report.RolledBack) != 1 || len(res.report.Busy) != 0 || !(*begun)[0].called("Rollback") {
+				t.Logf("transaction should be rolled back, got %+v and %v", res.report, res.err)
+				t.FailNow()
+			}
+		case <-time.After(time.Second):
+			t.Log("shutdown should return after deadline passes")
+			t.FailNow()
+		}
+		if !errors.Is(rows.Err(), ErrTxPoolResultClosed) {
+			t.Logf("expected error %v, got %v", ErrTxPoolResultClosed, rows.Err())
+			t.FailNow()
+		}
+	})
+
+	t.Run("should report transaction that is running a statement as busy", func(t *testing.T) {
+		p, _ := newShutdownPool(t)
+		tx := newBlockingTx()
+		p.beginTx = func(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+			return tx, nil
+		}
+
+		txCTX, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		savepointCTX, err := p.BeginTX(txCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		done := execAsync(p, txCTX)
+		waitUntil(t, tx.running.Load)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		type result struct {
+			report ShutdownReport
+			err    error
+		}
+		shutdown := make(chan result, 1)
+		go func() {
+			report, err := p.Shutdown(ctx)
+			shutdown <- result{report: report, err: err}
+		}()
+
+		waitUntil(t, func() bool { return len(p.ActiveTransactions()) == 0 })
+		if tx.called("Rollback") {
+			t.Log("transaction should not be rolled back while statement is running")
+			t.FailNow()
+		}
+		for _, ctx := range []context.Context{savepointCTX, txCTX} {
+			if err := p.CommitTX(ctx); !errors.Is(err, ErrTxPoolShutdown) {
+				t.Logf("expected error %v, got %v", ErrTxPoolShutdown, err)
+				t.FailNow()
+			}
+		}
+
+		close(tx.release)
+		<-done
+		res := <-shutdown
+		if res.err != nil || len(res.report.RolledBack) != 0 || len(res.report.Busy) != 2 || res.report.Busy[0].TxID != txIDOf(p, txCTX) {
+			t.Logf("expected 2 busy transactions starting from the owner, got %+v and %v", res.report, res.err)
+			t.FailNow()
+		}
+		if !tx.called("Rollback") || tx.overlap.Load() {
+			t.Log("transaction should be rolled back after statement return")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should rollback remaining transaction when deadline passes", func(t *testing.T) {
+		p, begun := newShutdownPool(t)
+
+		txCTX, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		joinedCTX, err := p.BeginTXWithPropagation(txCTX, PropagationRequired, pgx.TxOptions{})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		savepointCTX, err := p.BeginTX(txCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		report, err := p.Shutdown(ctx)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		rolledBack := report.RolledBack
+		if len(rolledBack) != 3 || len(report.Busy) != 0 || rolledBack[0].TxID != txIDOf(p, txCTX) {
+			t.Logf("expected 3 rolled back transactions starting from the owner, got %v", rolledBack)
+			t.FailNow()
+		}
+		tx := (*begun)[0]
+		if !tx.called("Rollback") || tx.children[0].called("Rollback") {
+			t.Log("only transaction that own the connection should be rolled back")
+			t.FailNow()
+		}
+		if p.TxStats().Aborted != 1 || len(p.ActiveTransactions()) != 0 {
+			t.Log("pool should be empty after shutdown")
+			t.FailNow()
+		}
+
+		for _, ctx := range []context.Context{savepointCTX, joinedCTX, txCTX} {
+			if err := p.CommitTX(ctx); !errors.Is(err, ErrTxPoolShutdown) {
+				t.Logf("expected error %v, got %v", ErrTxPoolShutdown, err)
+				t.FailNow()
+			}
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package pgxtxpool
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TxKind describe what is registered in the pool for a transaction id
+type TxKind string
+
+const (
+	// TxKindTransaction is a transaction that begun from pgxpool
+	TxKindTransaction TxKind = "transaction"
+	// TxKindSavepoint is a savepoint inside another transaction
+	TxKindSavepoint TxKind = "savepoint"
+	// TxKindJoined is a participant that join transaction owned by another context
+	TxKindJoined TxKind = "joined"
+	// TxKindNone is a scope that run without transaction
+	TxKindNone TxKind = "none"
+)
+
+// TxState describe current state of a registered transaction
+type TxState string
+
+const (
+	// TxStateActive is a transaction that can be committed
+	TxStateActive TxState = "active"
+	// TxStateRollbackOnly is a transaction that will be rolled back on commit
+	// because one of its participant has been rolled back
+	TxStateRollbackOnly TxState = "rollback_only"
+)
+
+// TxInfo is a snapshot of a transaction that registered in the pool
+// LastStatementAt is zero when no statement has been executed using the transaction
+// QueryCount of transaction that own a connection include statements of its savepoints and joined transactions
+type TxInfo struct {
+	TxID            TxID
+	Kind            TxKind
+	State           TxState
+	StartedAt       time.Time
+	Options         pgx.TxOptions
+	QueryCount      int64
+	LastStatementAt time.Time
+}
+
+// TxStats is aggregate counts of transaction since pool is created
+// Retried is number of attempts of WithTransactionRetry that failed and will be run again
+// Aborted is number of transactions rolled back because their context is done
+// or because they are still open when Shutdown deadline passes
+// only transaction that begun from pgxpool is counted,
+// savepoint, joined and scope without transaction are not counted
+type TxStats struct {
+	Begun      int64
+	Committed  int64
+	RolledBack int64
+	Reaped     int64
+	Aborted    int64
+	Retried    int64
+}
+
+// txStats is counters behind TxStats that maintained atomically
+type txStats struct {
+	begun      atomic.Int64
+	committed  atomic.Int64
+	rolledBack atomic.Int64
+	reaped     atomic.Int64
+	aborted    atomic.Int64
+	retried    atomic.Int64
+}
+
+func (s *txStats) recordBegin(conn *txConn) {
+	if conn.kind == TxKindTransaction {
+		s.begun.Add(1)
+	}
+}
+
+func (s *txStats) recordCommit(conn *txConn) {
+	if conn.kind == TxKindTransaction {
+		s.committed.Add(1)
+	}
+}
+
+func (s *txStats) recordRollback(conn *txConn) {
+	if conn.kind == TxKindTransaction {
+		s.rolledBack.Add(1)
+	}
+}
+
+func (s *txStats) recordReap(conn *txConn) {
+	if conn.kind == TxKindTransaction {
+		s.reaped.Add(1)
+	}
+}
+
+func (s *txStats) recordAbort(conn *txConn) {
+	if conn.kind == TxKindTransaction {
+		s.aborted.Add(1)
+	}
+}
+
+// recordRetry will count an attempt of WithTransactionRetry that will be retried
+func (s *txStats) recordRetry() {
+	s.retried.Add(1)
+}
+
+// ActiveTransactions will return a snapshot of every transaction that registered in the pool
+// sorted from the oldest one
+func (p *Pool) ActiveTransactions() []TxInfo {
+	var infos []TxInfo
+	p.txpool.Range(func(key, value any) bool {
+		infos = append(infos, newTxInfo(key.(TxID), value.(*txConn)))
+		return true
+	})
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartedAt.Before(infos[j].StartedAt)
+	})
+	return infos
+}
+
+// TxStats will return aggregate counts of transaction since pool is created
+func (p *Pool) TxStats() TxStats {
+	return TxStats{
+		Begun:      p.stats.begun.Load(),
+		Committed:  p.stats.committed.Load(),
+		RolledBack: p.stats.rolledBack.Load(),
+		Reaped:     p.stats.reaped.Load(),
+		Aborted:    p.stats.aborted.Load(),
+		Retried:    p.stats.retried.Load(),
+	}
+}
+
+// newTxInfo will create a snapshot of a registered transaction
+func newTxInfo(txID TxID, conn *txConn) TxInfo {
+	info := TxInfo{
+		TxID:       txID,
+		Kind:       conn.kind,
+		State:      TxStateActive,
+		StartedAt:  conn.startedAt,
+		Options:    conn.options,
+		QueryCount: conn.queryCount.Load(),
+	}
+	if conn.rollbackOnly.Load() || (conn.owner != nil && conn.owner.rollbackOnly.Load()) {
+		info.State = TxStateRollbackOnly
+	}
+	if lastStatementAt := conn.lastStatementAt.Load(); lastStatementAt > 0 {
+		info.LastStatementAt = time.Unix(0, lastStatementAt)
+	}
+	return info
+}
@@ -0,0 +1,132 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestActiveTransactions(t *testing.T) {
+	p, _ := newFakePool()
+	serializable := pgx.TxOptions{IsoLevel: pgx.Serializable}
+
+	outerCTX, err := p.BeginTXWithOptions(context.Background(), serializable)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	time.Sleep(time.Millisecond)
+
+	innerCTX, err := p.BeginTXWithPropagation(outerCTX, PropagationRequired, pgx.TxOptions{})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	p.Exec(outerCTX, "SELECT 1")
+	var n int
+	p.QueryRow(outerCTX, "SELECT 1").Scan(&n)
+	if err := p.RollbackTX(innerCTX); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	infos := p.ActiveTransactions()
+	if len(infos) != 1 {
+		t.Logf("expected 1 active transaction, got %d", len(infos))
+		t.FailNow()
+	}
+
+	info := infos[0]
+	if info.TxID != txIDOf(p, outerCTX) ||
+		info.Kind != TxKindTransaction ||
+		info.State != TxStateRollbackOnly ||
+		info.Options != serializable ||
+		info.QueryCount != 2 ||
+		info.StartedAt.IsZero() ||
+		info.LastStatementAt.Before(info.StartedAt) {
+		t.Logf("unexpected transaction info %+v", info)
+		t.FailNow()
+	}
+
+	// snapshot should not change after transaction is removed
+	p.CommitTX(outerCTX)
+	if len(p.ActiveTransactions()) != 0 || infos[0].QueryCount != 2 {
+		t.Log("snapshot should not be affected by pool changes")
+		t.FailNow()
+	}
+}
+
+func TestStatementCount(t *testing.T) {
+	t.Run("should count statements of savepoint and joined transaction on owner", func(t *testing.T) {
+		p, _ := newFakePool()
+		hooks := &endHooks{}
+		p.hooks = multiHooks{hooks}
+
+		err := p.WithTransaction(context.Background(), func(ctx context.Context) error {
+			p.Exec(ctx, "SELECT 1")
+			if err := p.WithTransaction(ctx, func(ctx context.Context) error {
+				p.Exec(ctx, "SELECT 1")
+				p.Exec(ctx, "SELECT 1")
+				return nil
+			}); err != nil {
+				return err
+			}
+			return p.WithTransactionPropagation(ctx, PropagationRequired, pgx.TxOptions{}, func(ctx context.Context) error {
+				_, err := p.Exec(ctx, "SELECT 1")
+				return err
+			})
+		})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if len(hooks.infos) != 1 || hooks.infos[0].QueryCount != 4 {
+			t.Logf("owner should count 4 statements, got %+v", hooks.infos)
+			t.FailNow()
+		}
+	})
+}
+
+func TestTxStats(t *testing.T) {
+	p, _ := newFakePool()
+	p.reaper = &reaper{maxTxLifetime: time.Minute, onTxReaped: func(txID TxID, age time.Duration) {}}
+	ctx := context.Background()
+
+	// committed
+	p.WithTransaction(ctx, func(ctx context.Context) error {
+		// savepoint is not counted
+		return p.WithTransaction(ctx, func(ctx context.Context) error { return nil })
+	})
+
+	// rolled back
+	p.WithTransaction(ctx, func(ctx context.Context) error { return errors.New("something went wrong") })
+
+	// reaped
+	reapCTX, _ := p.BeginTX(ctx)
+	conn, _ := p.getTXConnFromContext(reapCTX)
+	conn.startedAt = time.Now().Add(-time.Hour)
+	p.reapTX()
+
+	// aborted
+	abortCTX, cancel := context.WithCancel(ctx)
+	p.BeginTX(abortCTX)
+	cancel()
+	waitUntil(t, func() bool { return p.TxStats().Aborted == 1 })
+
+	expStats := TxStats{
+		Begun:      4,
+		Committed:  1,
+		RolledBack: 1,
+		Reaped:     1,
+		Aborted:    1,
+	}
+	if stats := p.TxStats(); stats != expStats {
+		t.Logf("expected stats %+v, got %+v", expStats, stats)
+		t.FailNow()
+	}
+}
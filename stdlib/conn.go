@@ -0,0 +1,207 @@
+package stdlib
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	pgxtxpool "github.com/rasatmaja/pgx-txpool"
+)
+
+// ErrTxInProgress will indicate that BeginTx is called on a connection that already in a transaction
+var ErrTxInProgress = errors.New("pgxtxpool/stdlib: transaction already in progress")
+
+// conn is a virtual connection that execute statements using pool
+// txCTX is set while connection is used by *sql.Tx, it carry the transaction id registered in the pool
+type conn struct {
+	pool  *pgxtxpool.Pool
+	txCTX context.Context
+}
+
+var (
+	_ driver.Conn               = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+)
+
+// queryContext will return context that used to execute a statement
+// when connection is used by *sql.Tx, transaction id from txCTX is injected into ctx
+func (c *conn) queryContext(ctx context.Context) context.Context {
+	if c.txCTX == nil {
+		return ctx
+	}
+	txID, _ := c.pool.TxIDFromContext(c.txCTX)
+	return c.pool.ContextWithTxID(ctx, txID)
+}
+
+// Prepare will create a statement that executed using the connection
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext will create a statement that executed using the connection
+// statement is not prepared on the server, pgx will prepare and cache it when executed
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+// Close do nothing, connection is owned by the pool
+func (c *conn) Close() error {
+	return nil
+}
+
+// Begin will begin a transaction
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx will begin a transaction and register it in the pool
+// if ctx already carry an active transaction then a savepoint is created
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if c.txCTX != nil {
+		return nil, ErrTxInProgress
+	}
+
+	txOptions, err := toTxOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	txCTX, err := c.pool.BeginTXWithOptions(ctx, txOptions)
+	if err != nil {
+		return nil, err
+	}
+	c.txCTX = txCTX
+	return &tx{conn: c}, nil
+}
+
+// ExecContext will execute a statement using pool or the transaction carried in the context
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	commandTag, err := c.pool.Exec(c.queryContext(ctx), query, toArgs(args)...)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(commandTag.RowsAffected()), nil
+}
+
+// QueryContext will execute a query using pool or the transaction carried in the context
+// only types that rows convert from binary format are requested in binary format
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	pgxRows, err := c.pool.Query(c.queryContext(ctx), query, append([]any{resultFormats}, toArgs(args)...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{rows: pgxRows}, nil
+}
+
+// CheckNamedValue will accept every value, it is encoded by pgx
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	return nil
+}
+
+// Ping will ping the database using the pool
+func (c *conn) Ping(ctx context.Context) error {
+	return c.pool.Ping(ctx)
+}
+
+// tx is a driver.Tx that commit or rollback transaction registered in the pool
+type tx struct {
+	conn *conn
+}
+
+// Commit will commit the transaction using pool CommitTX
+func (t *tx) Commit() error {
+	txCTX := t.conn.txCTX
+	t.conn.txCTX = nil
+	return t.conn.pool.CommitTX(txCTX)
+}
+
+// Rollback will rollback the transaction using pool RollbackTX
+func (t *tx) Rollback() error {
+	txCTX := t.conn.txCTX
+	t.conn.txCTX = nil
+	return t.conn.pool.RollbackTX(txCTX)
+}
+
+// stmt is a driver.Stmt that execute query using its connection
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+// Close do nothing
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput return -1, number of placeholder is checked by postgres
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+// Exec will execute statement using its connection
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), toNamedValues(args))
+}
+
+// Query will execute query using its connection
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), toNamedValues(args))
+}
+
+// ExecContext will execute statement using its connection
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+// QueryContext will execute query using its connection
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+// toTxOptions will convert database/sql transaction options to pgx transaction options
+func toTxOptions(opts driver.TxOptions) (pgx.TxOptions, error) {
+	var txOptions pgx.TxOptions
+	switch sql.IsolationLevel(opts.Isolation) {
+	case sql.LevelDefault:
+	case sql.LevelReadUncommitted:
+		txOptions.IsoLevel = pgx.ReadUncommitted
+	case sql.LevelReadCommitted:
+		txOptions.IsoLevel = pgx.ReadCommitted
+	case sql.LevelRepeatableRead, sql.LevelSnapshot:
+		txOptions.IsoLevel = pgx.RepeatableRead
+	case sql.LevelSerializable:
+		txOptions.IsoLevel = pgx.Serializable
+	default:
+		return txOptions, fmt.Errorf("pgxtxpool/stdlib: unsupported isolation level: %s", sql.IsolationLevel(opts.Isolation))
+	}
+	if opts.ReadOnly {
+		txOptions.AccessMode = pgx.ReadOnly
+	}
+	return txOptions, nil
+}
+
+// toArgs will convert named values to positional arguments
+func toArgs(nvs []driver.NamedValue) []any {
+	args := make([]any, len(nvs))
+	for i, nv := range nvs {
+		args[i] = nv.Value
+	}
+	return args
+}
+
+// toNamedValues will convert values to named values
+func toNamedValues(values []driver.Value) []driver.NamedValue {
+	nvs := make([]driver.NamedValue, len(values))
+	for i, value := range values {
+		nvs[i] = driver.NamedValue{Ordinal: i + 1, Value: value}
+	}
+	return nvs
+}
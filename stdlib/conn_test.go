@@ -0,0 +1,56 @@
+package stdlib
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestToTxOptions(t *testing.T) {
+	t.Run("should map isolation level and access mode", func(t *testing.T) {
+		txOptions, err := toTxOptions(driver.TxOptions{
+			Isolation: driver.IsolationLevel(sql.LevelSerializable),
+			ReadOnly:  true,
+		})
+		if err != nil {
+			t.Log("unexpected error:", err)
+			t.FailNow()
+		}
+		if txOptions.IsoLevel != pgx.Serializable || txOptions.AccessMode != pgx.ReadOnly {
+			t.Log("unexpected transaction options:", txOptions)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should use server default when isolation level is default", func(t *testing.T) {
+		txOptions, err := toTxOptions(driver.TxOptions{})
+		if err != nil {
+			t.Log("unexpected error:", err)
+			t.FailNow()
+		}
+		if txOptions != (pgx.TxOptions{}) {
+			t.Log("unexpected transaction options:", txOptions)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should return error when isolation level is not supported", func(t *testing.T) {
+		_, err := toTxOptions(driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelLinearizable)})
+		if err == nil {
+			t.Log("expected error for unsupported isolation level")
+			t.FailNow()
+		}
+	})
+}
+
+func TestConnBeginTx(t *testing.T) {
+	t.Run("should return error when connection already in a transaction", func(t *testing.T) {
+		c := &conn{txCTX: t.Context()}
+		if _, err := c.BeginTx(t.Context(), driver.TxOptions{}); err != ErrTxInProgress {
+			t.Log("expected ErrTxInProgress, got:", err)
+			t.FailNow()
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package stdlib
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// resultFormats is formats of query results that requested by QueryContext
+// like github.com/jackc/pgx/v5/stdlib, only intrinsic types are returned in binary format,
+// every other type is returned in postgres text format
+var resultFormats = pgx.QueryResultFormatsByOID{
+	pgtype.BoolOID:        pgtype.BinaryFormatCode,
+	pgtype.ByteaOID:       pgtype.BinaryFormatCode,
+	pgtype.CIDOID:         pgtype.BinaryFormatCode,
+	pgtype.DateOID:        pgtype.BinaryFormatCode,
+	pgtype.Float4OID:      pgtype.BinaryFormatCode,
+	pgtype.Float8OID:      pgtype.BinaryFormatCode,
+	pgtype.Int2OID:        pgtype.BinaryFormatCode,
+	pgtype.Int4OID:        pgtype.BinaryFormatCode,
+	pgtype.Int8OID:        pgtype.BinaryFormatCode,
+	pgtype.OIDOID:         pgtype.BinaryFormatCode,
+	pgtype.TimestampOID:   pgtype.BinaryFormatCode,
+	pgtype.TimestamptzOID: pgtype.BinaryFormatCode,
+	pgtype.XIDOID:         pgtype.BinaryFormatCode,
+}
+
+// rows is a driver.Rows on top of pgx.Rows
+type rows struct {
+	rows    pgx.Rows
+	columns []string
+
+	// values convert raw value of each column, it is planned on the first Next
+	values []valueFunc
+}
+
+// valueFunc will convert raw value of a column to a value supported by database/sql
+type valueFunc func(src []byte) (driver.Value, error)
+
+// Columns will return name of the columns
+func (r *rows) Columns() []string {
+	if r.columns == nil {
+		fields := r.rows.FieldDescriptions()
+		r.columns = make([]string, len(fields))
+		for i, field := range fields {
+			r.columns[i] = field.Name
+		}
+	}
+	return r.columns
+}
+
+// Close will close pgx.Rows
+func (r *rows) Close() error {
+	r.rows.Close()
+	return r.rows.Err()
+}
+
+// Next will read next row into dest
+// values are converted the same way github.com/jackc/pgx/v5/stdlib does,
+// json is returned as bytes and array and other types as string, both in postgres text format
+func (r *rows) Next(dest []driver.Value) error {
+	if r.values == nil {
+		r.values = r.planValues()
+	}
+
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+
+	for i, raw := range r.rows.RawValues() {
+		if raw == nil {
+			dest[i] = nil
+			continue
+		}
+		value, err := r.values[i](raw)
+		if err != nil {
+			return fmt.Errorf("convert field %d failed: %w", i, err)
+		}
+		dest[i] = value
+	}
+	return nil
+}
+
+// planValues will plan conversion of every column
+// using type map of the connection that run the query
+func (r *rows) planValues() []valueFunc {
+	m := pgtype.NewMap()
+	if conn := r.rows.Conn(); conn != nil {
+		m = conn.TypeMap()
+	}
+
+	fields := r.rows.FieldDescriptions()
+	values := make([]valueFunc, len(fields))
+	for i, field := range fields {
+		values[i] = planValue(m, field)
+	}
+	return values
+}
+
+// planValue will plan conversion of a column based on its type
+func planValue(m *pgtype.Map, field pgconn.FieldDescription) valueFunc {
+	switch field.DataTypeOID {
+	case pgtype.BoolOID:
+		return scanValue(m, field, func(v bool) (driver.Value, error) { return v, nil })
+	case pgtype.ByteaOID, pgtype.JSONOID, pgtype.JSONBOID, pgtype.XMLOID:
+		return scanValue(m, field, func(v []byte) (driver.Value, error) { return v, nil })
+	case pgtype.CIDOID, pgtype.OIDOID, pgtype.XIDOID:
+		return scanValue(m, field, pgtype.Uint32.Value)
+	case pgtype.DateOID:
+		return scanValue(m, field, pgtype.Date.Value)
+	case pgtype.Float4OID:
+		return scanValue(m, field, func(v float32) (driver.Value, error) { return float64(v), nil })
+	case pgtype.Float8OID:
+		return scanValue(m, field, func(v float64) (driver.Value, error) { return v, nil })
+	case pgtype.Int2OID:
+		return scanValue(m, field, func(v int16) (driver.Value, error) { return int64(v), nil })
+	case pgtype.Int4OID:
+		return scanValue(m, field, func(v int32) (driver.Value, error) { return int64(v), nil })
+	case pgtype.Int8OID:
+		return scanValue(m, field, func(v int64) (driver.Value, error) { return v, nil })
+	case pgtype.TimestampOID:
+		return scanValue(m, field, pgtype.Timestamp.Value)
+	case pgtype.TimestamptzOID:
+		return scanValue(m, field, pgtype.Timestamptz.Value)
+	default:
+		return scanValue(m, field, func(v string) (driver.Value, error) { return v, nil })
+	}
+}
+
+// scanValue will scan raw value of a column into T then convert it
+func scanValue[T any](m *pgtype.Map, field pgconn.FieldDescription, convert func(T) (driver.Value, error)) valueFunc {
+	plan := m.PlanScan(field.DataTypeOID, field.Format, new(T))
+	return func(src []byte) (driver.Value, error) {
+		var v T
+		if err := plan.Scan(src, &v); err != nil {
+			return nil, err
+		}
+		return convert(v)
+	}
+}
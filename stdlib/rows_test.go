@@ -0,0 +1,114 @@
+package stdlib
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fakeRows is a pgx.Rows that return a single row of raw values
+type fakeRows struct {
+	pgx.Rows
+	fields []pgconn.FieldDescription
+	raw    [][]byte
+	read   bool
+}
+
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription { return r.fields }
+func (r *fakeRows) RawValues() [][]byte                          { return r.raw }
+func (r *fakeRows) Conn() *pgx.Conn                              { return nil }
+func (r *fakeRows) Err() error                                   { return nil }
+
+func (r *fakeRows) Next() bool {
+	next := !r.read
+	r.read = true
+	return next
+}
+
+func TestRowsNext(t *testing.T) {
+	m := pgtype.NewMap()
+	encode := func(oid uint32, value any) []byte {
+		raw, err := m.Encode(oid, pgtype.BinaryFormatCode, value, nil)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		return raw
+	}
+
+	tests := []struct {
+		name   string
+		oid    uint32
+		format int16
+		raw    []byte
+		want   driver.Value
+	}{
+		{name: "null", oid: pgtype.TextOID, raw: nil, want: nil},
+		{name: "text", oid: pgtype.TextOID, raw: []byte("text"), want: "text"},
+		{name: "bool", oid: pgtype.BoolOID, format: pgtype.BinaryFormatCode, raw: encode(pgtype.BoolOID, true), want: true},
+		{name: "int4", oid: pgtype.Int4OID, format: pgtype.BinaryFormatCode, raw: encode(pgtype.Int4OID, int32(7)), want: int64(7)},
+		{name: "int8 above 2^53", oid: pgtype.Int8OID, format: pgtype.BinaryFormatCode, raw: encode(pgtype.Int8OID, int64(1<<53+1)), want: int64(1<<53 + 1)},
+		{name: "float4", oid: pgtype.Float4OID, format: pgtype.BinaryFormatCode, raw: encode(pgtype.Float4OID, float32(1.5)), want: float64(1.5)},
+		{name: "numeric", oid: pgtype.NumericOID, raw: []byte("123.45"), want: "123.45"},
+		{name: "uuid", oid: pgtype.UUIDOID, format: pgtype.BinaryFormatCode, raw: encode(pgtype.UUIDOID, [16]byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0, 0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0}), want: "12345678-9abc-def0-1234-56789abcdef0"},
+		{name: "text array", oid: pgtype.TextArrayOID, raw: []byte(`{a,"b c"}`), want: `{a,"b c"}`},
+		{name: "int8 array", oid: pgtype.Int8ArrayOID, raw: []byte("{1,9007199254740993}"), want: "{1,9007199254740993}"},
+	}
+
+	for _, tt := range tests {
+		t.Run("should convert "+tt.name, func(t *testing.T) {
+			r := &rows{rows: &fakeRows{
+				fields: []pgconn.FieldDescription{{Name: "value", DataTypeOID: tt.oid, Format: tt.format}},
+				raw:    [][]byte{tt.raw},
+			}}
+			dest := make([]driver.Value, 1)
+			if err := r.Next(dest); err != nil {
+				t.Log("unexpected error:", err)
+				t.FailNow()
+			}
+			if dest[0] != tt.want {
+				t.Logf("expected %v (%T), got %v (%T)", tt.want, tt.want, dest[0], dest[0])
+				t.FailNow()
+			}
+			if err := r.Next(dest); err != io.EOF {
+				t.Logf("expected %v, got %v", io.EOF, err)
+				t.FailNow()
+			}
+		})
+	}
+
+	jsonTests := []struct {
+		name   string
+		oid    uint32
+		format int16
+		raw    []byte
+	}{
+		{name: "json", oid: pgtype.JSONOID, raw: []byte(`{"b": 9007199254740993, "a": [1, 2]}`)},
+		{name: "jsonb", oid: pgtype.JSONBOID, raw: []byte(`{"a": [1, 2], "b": 9007199254740993}`)},
+		{name: "binary jsonb", oid: pgtype.JSONBOID, format: pgtype.BinaryFormatCode, raw: append([]byte{1}, `{"a": [1, 2], "b": 9007199254740993}`...)},
+	}
+
+	for _, tt := range jsonTests {
+		t.Run("should keep "+tt.name+" as it is returned by postgres", func(t *testing.T) {
+			r := &rows{rows: &fakeRows{
+				fields: []pgconn.FieldDescription{{Name: "value", DataTypeOID: tt.oid, Format: tt.format}},
+				raw:    [][]byte{tt.raw},
+			}}
+			dest := make([]driver.Value, 1)
+			if err := r.Next(dest); err != nil {
+				t.Log("unexpected error:", err)
+				t.FailNow()
+			}
+			want := bytes.TrimPrefix(tt.raw, []byte{1})
+			if got, ok := dest[0].([]byte); !ok || !bytes.Equal(got, want) {
+				t.Logf("expected %s, got %v (%T)", want, dest[0], dest[0])
+				t.FailNow()
+			}
+		})
+	}
+}
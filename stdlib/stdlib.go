@@ -0,0 +1,54 @@
+// Package stdlib expose pgxtxpool.Pool as *sql.DB
+// so libraries that only accept database/sql can join the transaction carried in the context
+// ex:
+//
+//	db := stdlib.OpenDB(pool)
+//	ctx, _ := pool.BeginTX(ctx)
+//	db.ExecContext(ctx, "INSERT ...") // run inside the transaction
+//
+// transaction begun using BeginTx on *sql.DB is registered in the pool registry,
+// so it is tracked like transaction begun using pgxtxpool.Pool.BeginTX
+package stdlib
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	pgxtxpool "github.com/rasatmaja/pgx-txpool"
+)
+
+// ErrOpenNotSupported will indicate that driver can not open connection using a name,
+// use OpenDB instead
+var ErrOpenNotSupported = errors.New("pgxtxpool/stdlib: open by name is not supported, use OpenDB")
+
+// OpenDB will create *sql.DB on top of pool
+// connections of *sql.DB do not hold a real connection,
+// every statement is executed using the pool or the transaction carried in the context
+func OpenDB(pool *pgxtxpool.Pool) *sql.DB {
+	return sql.OpenDB(&connector{pool: pool})
+}
+
+// connector is a driver.Connector that create virtual connection on top of pool
+type connector struct {
+	pool *pgxtxpool.Pool
+}
+
+// Connect will create a virtual connection
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &conn{pool: c.pool}, nil
+}
+
+// Driver will return driver of the connector
+func (c *connector) Driver() driver.Driver {
+	return txpoolDriver{}
+}
+
+// txpoolDriver is a driver.Driver that can not be opened by name
+type txpoolDriver struct{}
+
+// Open will always return ErrOpenNotSupported
+func (txpoolDriver) Open(name string) (driver.Conn, error) {
+	return nil, ErrOpenNotSupported
+}
@@ -0,0 +1,76 @@
+package pgxtxpool
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// staleTXMode decide what happen when context carry a transaction that can not be used
+// ex: transaction already committed or it belongs to another pool
+type staleTXMode int
+
+const (
+	// staleTXIgnore will run query without transaction silently
+	staleTXIgnore staleTXMode = iota
+	// staleTXWarn will log a warning then run query without transaction
+	staleTXWarn
+	// staleTXReject will return an error instead of running query
+	staleTXReject
+)
+
+// checkTXContext will check context that does not carry an active transaction of the pool
+// it return an error in strict mode when context carry a stale or foreign transaction id,
+// in lenient mode the error is logged as a warning and query run without transaction
+func (p *Pool) checkTXContext(ctx context.Context) error {
+	if p.staleTXMode == staleTXIgnore {
+		return nil
+	}
+
+	var err error
+	if txID, ok := p.TxIDFromContext(ctx); ok {
+		err = fmt.Errorf("%w: %s", ErrTxPoolStaleTX, txID)
+	} else if txID, ok := TxIDFromContext(ctx); ok {
+		err = fmt.Errorf("%w: %s", ErrTxPoolForeignTX, txID)
+	} else {
+		return nil
+	}
+
+	if p.staleTXMode == staleTXWarn {
+		p.staleTXLogger.WarnContext(ctx, "pgxtxpool: query run without transaction", "error", err)
+		return nil
+	}
+	return err
+}
+
+// errRow is a pgx.Row that return an error on Scan
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(dest ...any) error {
+	return r.err
+}
+
+// errBatchResults is a pgx.BatchResults that return an error on every call
+type errBatchResults struct {
+	err error
+}
+
+func (b errBatchResults) Exec() (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, b.err
+}
+
+func (b errBatchResults) Query() (pgx.Rows, error) {
+	return nil, b.err
+}
+
+func (b errBatchResults) QueryRow() pgx.Row {
+	return errRow(b)
+}
+
+func (b errBatchResults) Close() error {
+	return b.err
+}
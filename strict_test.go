@@ -0,0 +1,156 @@
+package pgxtxpool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestStrictMode(t *testing.T) {
+	queries := []struct {
+		name string
+		call func(ctx context.Context, p *Pool) error
+	}{
+		{
+			name: "Exec",
+			call: func(ctx context.Context, p *Pool) error {
+				_, err := p.Exec(ctx, "SELECT 1")
+				return err
+			},
+		},
+		{
+			name: "Query",
+			call: func(ctx context.Context, p *Pool) error {
+				_, err := p.Query(ctx, "SELECT 1")
+				return err
+			},
+		},
+		{
+			name: "QueryRow",
+			call: func(ctx context.Context, p *Pool) error {
+				var n int
+				return p.QueryRow(ctx, "SELECT 1").Scan(&n)
+			},
+		},
+		{
+			name: "SendBatch",
+			call: func(ctx context.Context, p *Pool) error {
+				return p.SendBatch(ctx, &pgx.Batch{}).Close()
+			},
+		},
+		{
+			name: "CopyFrom",
+			call: func(ctx context.Context, p *Pool) error {
+				_, err := p.CopyFrom(ctx, pgx.Identifier{"users"}, []string{"id"}, pgx.CopyFromRows(nil))
+				return err
+			},
+		},
+		{
+			name: "Begin",
+			call: func(ctx context.Context, p *Pool) error {
+				_, err := p.Begin(ctx)
+				return err
+			},
+		},
+	}
+
+	for _, q := range queries {
+		t.Run("should reject "+q.name+" after transaction is committed", func(t *testing.T) {
+			p, _ := newFakePool()
+			p.staleTXMode = staleTXReject
+
+			ctx, err := p.BeginTX(context.Background())
+			if err != nil {
+				t.Log(err)
+				t.FailNow()
+			}
+			if err := p.CommitTX(ctx); err != nil {
+				t.Log(err)
+				t.FailNow()
+			}
+
+			err = q.call(ctx, p)
+			if !errors.Is(err, ErrTxPoolStaleTX) || !errors.Is(err, ErrTxPoolNotFound) {
+				t.Logf("expected error %v, got %v", ErrTxPoolStaleTX, err)
+				t.FailNow()
+			}
+		})
+	}
+
+	t.Run("should reject query using transaction of another pool", func(t *testing.T) {
+		poolA, _ := newFakePool()
+		poolB, _ := newFakePool()
+		poolB.staleTXMode = staleTXReject
+
+		ctx, err := poolA.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		defer poolA.RollbackTX(ctx)
+
+		_, err = poolB.Exec(ctx, "SELECT 1")
+		if !errors.Is(err, ErrTxPoolForeignTX) || !errors.Is(err, ErrTxPoolNotFound) {
+			t.Logf("expected error %v, got %v", ErrTxPoolForeignTX, err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should not reject query inside scope without transaction", func(t *testing.T) {
+		p, _ := newFakePool()
+		p.staleTXMode = staleTXReject
+
+		ctx, err := p.BeginTXWithPropagation(context.Background(), PropagationNotSupported, pgx.TxOptions{})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		defer p.CommitTX(ctx)
+
+		if err := p.checkTXContext(context.Background()); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if _, _, err := p.useTXFromContext(ctx); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	})
+}
+
+func TestLenientMode(t *testing.T) {
+	t.Run("should log warning then run query without transaction", func(t *testing.T) {
+		// nothing should listen on port 1, so query without transaction fail to connect
+		pool, err := pgxpool.New(context.Background(), "postgres://postgres@127.0.0.1:1/postgres?sslmode=disable")
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		defer pool.Close()
+
+		buf := &bytes.Buffer{}
+		p, _ := newFakePool()
+		p.Pool = pool
+		p.staleTXMode = staleTXWarn
+		p.staleTXLogger = slog.New(slog.NewTextHandler(buf, nil))
+
+		ctx, _ := p.BeginTX(context.Background())
+		p.RollbackTX(ctx)
+
+		_, err = p.Exec(ctx, "SELECT 1")
+		if err == nil || errors.Is(err, ErrTxPoolStaleTX) {
+			t.Logf("expected query to run without transaction, got %v", err)
+			t.FailNow()
+		}
+		if !strings.Contains(buf.String(), "level=WARN") || !strings.Contains(buf.String(), "no longer active") {
+			t.Logf("expected warning, got %q", buf.String())
+			t.FailNow()
+		}
+	})
+}
@@ -5,12 +5,16 @@ package integration
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	pgxtxpool "github.com/rasatmaja/pgx-txpool"
+	"github.com/rasatmaja/pgx-txpool/outbox"
+	"github.com/rasatmaja/pgx-txpool/stdlib"
 	"github.com/rasatmaja/pgx-txpool/tests/integration/model"
 	"github.com/rasatmaja/pgx-txpool/tests/integration/repository"
 	"github.com/rasatmaja/pgx-txpool/tests/integration/service"
@@ -20,6 +24,7 @@ import (
 )
 
 type TestSuite struct {
+	db   *pgxtxpool.Pool
 	repo *repository.Repository
 	srv  *service.Service
 }
@@ -33,6 +38,11 @@ func TestMain(t *testing.T) {
 
 	// run tests
 	t.Run("TestMigration", suite.Migration)
+	t.Run("TestQueryJoinTransaction", suite.QueryJoinTransaction)
+	t.Run("TestNestedTransaction", suite.NestedTransaction)
+	t.Run("TestTransactionOptions", suite.TransactionOptions)
+	t.Run("TestOutbox", suite.Outbox)
+	t.Run("TestStdlib", suite.Stdlib)
 	t.Run("TestCreateUser", suite.CreateUser)
 	t.Run("TestTransferBalace", suite.TransferBalance)
 }
@@ -76,7 +86,7 @@ func (ts *TestSuite) Setup(ctx context.Context) {
 	pgPort = exposedPort.Port()
 
 	// setup database
-	db := pgxtxpool.New(
+	db, err := pgxtxpool.NewWithContext(ctx,
 		pgxtxpool.SetHost(pgHost, pgPort),
 		pgxtxpool.SetCredential(pgUsername, pgPassword),
 		pgxtxpool.SetDatabase(pgDatabase),
@@ -84,12 +94,13 @@ func (ts *TestSuite) Setup(ctx context.Context) {
 		pgxtxpool.WithMaxConns(20),
 		pgxtxpool.WithMaxIdleConns("30s"),
 		pgxtxpool.WithMaxConnLifetime("5m"),
+		pgxtxpool.WithPing(5*time.Second),
 	)
-
-	if err := db.Ping(ctx); err != nil {
+	if err != nil {
 		panic(err)
 	}
 
+	ts.db = db
 	ts.repo = repository.NewRepository(db)
 	ts.srv = service.NewService(ts.repo)
 }
@@ -113,6 +124,213 @@ func (ts *TestSuite) Migration(t *testing.T) {
 	assert.ElementsMatch(t, []string{"id", "transaction_origin_id", "transaction_destination_id", "amount"}, columnsTransactionsTransfer)
 }
 
+// QueryJoinTransaction tests that every query entry point on pool
+// can see rows written by the transaction found in context
+func (ts *TestSuite) QueryJoinTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	trxCTX, err := ts.db.BeginTX(ctx)
+	assert.NoError(t, err, "failed to begin transaction")
+	defer ts.db.RollbackTX(trxCTX)
+
+	// write using CopyFrom
+	copied, err := ts.db.CopyFrom(trxCTX,
+		pgx.Identifier{"users"},
+		[]string{"id", "name", "balance"},
+		pgx.CopyFromRows([][]any{{"USRTX01", "Copy", 100}}),
+	)
+	assert.NoError(t, err, "failed to copy users")
+	assert.Equal(t, int64(1), copied)
+
+	// write using SendBatch
+	batch := &pgx.Batch{}
+	batch.Queue(`INSERT INTO users (id, name, balance) VALUES ($1, $2, $3)`, "USRTX02", "Batch", 200)
+	err = ts.db.SendBatch(trxCTX, batch).Close()
+	assert.NoError(t, err, "failed to send batch")
+
+	// read using QueryRow
+	var total int
+	err = ts.db.QueryRow(trxCTX, `SELECT COUNT(*) FROM users WHERE id IN ('USRTX01', 'USRTX02')`).Scan(&total)
+	assert.NoError(t, err, "failed to query row in transaction")
+	assert.Equal(t, 2, total, "query row should see rows written by the transaction")
+
+	// outside transaction rows should not be visible
+	err = ts.db.QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE id IN ('USRTX01', 'USRTX02')`).Scan(&total)
+	assert.NoError(t, err, "failed to query row outside transaction")
+	assert.Equal(t, 0, total, "query row outside transaction should not see uncommitted rows")
+}
+
+// NestedTransaction tests that nested BeginTX create a savepoint
+// and outer transaction still usable after savepoint is rolled back
+func (ts *TestSuite) NestedTransaction(t *testing.T) {
+	ctx := context.Background()
+	query := `INSERT INTO users (id, name, balance) VALUES ($1, $2, $3)`
+	count := `SELECT COUNT(*) FROM users WHERE id IN ('USRNT01', 'USRNT02')`
+
+	outerCTX, err := ts.db.BeginTX(ctx)
+	assert.NoError(t, err, "failed to begin outer transaction")
+	defer ts.db.RollbackTX(outerCTX)
+
+	_, err = ts.db.Exec(outerCTX, query, "USRNT01", "Outer", 100)
+	assert.NoError(t, err, "failed to insert user in outer transaction")
+
+	innerCTX, err := ts.db.BeginTX(outerCTX)
+	assert.NoError(t, err, "failed to begin nested transaction")
+
+	_, err = ts.db.Exec(innerCTX, query, "USRNT02", "Inner", 200)
+	assert.NoError(t, err, "failed to insert user in nested transaction")
+
+	var total int
+	err = ts.db.QueryRow(innerCTX, count).Scan(&total)
+	assert.NoError(t, err, "failed to count users in nested transaction")
+	assert.Equal(t, 2, total, "nested transaction should see rows from outer transaction")
+
+	err = ts.db.RollbackTX(innerCTX)
+	assert.NoError(t, err, "failed to rollback nested transaction")
+
+	err = ts.db.QueryRow(outerCTX, count).Scan(&total)
+	assert.NoError(t, err, "outer transaction should still usable after savepoint rollback")
+	assert.Equal(t, 1, total, "rolled back savepoint rows should not be visible")
+}
+
+// TransactionOptions tests that BeginTXWithOptions begin a transaction
+// using requested isolation level and access mode
+func (ts *TestSuite) TransactionOptions(t *testing.T) {
+	ctx := context.Background()
+
+	trxCTX, err := ts.db.BeginTXWithOptions(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.Serializable,
+		AccessMode: pgx.ReadOnly,
+	})
+	assert.NoError(t, err, "failed to begin transaction with options")
+	defer ts.db.RollbackTX(trxCTX)
+
+	var isoLevel, readOnly string
+	err = ts.db.QueryRow(trxCTX, `SHOW transaction_isolation`).Scan(&isoLevel)
+	assert.NoError(t, err, "failed to get transaction isolation")
+	assert.Equal(t, "serializable", isoLevel)
+
+	err = ts.db.QueryRow(trxCTX, `SHOW transaction_read_only`).Scan(&readOnly)
+	assert.NoError(t, err, "failed to get transaction read only")
+	assert.Equal(t, "on", readOnly)
+
+	opts, err := ts.db.TxOptions(trxCTX)
+	assert.NoError(t, err, "failed to get transaction options from context")
+	assert.Equal(t, pgx.Serializable, opts.IsoLevel)
+	assert.Equal(t, pgx.ReadOnly, opts.AccessMode)
+}
+
+// Stdlib tests that *sql.DB from stdlib package join transaction carried in context
+// and transaction begun using *sql.DB is registered in the pool
+func (ts *TestSuite) Stdlib(t *testing.T) {
+	ctx := context.Background()
+	db := stdlib.OpenDB(ts.db)
+	defer db.Close()
+	count := `SELECT COUNT(*) FROM users WHERE id IN ('USRSQL01', 'USRSQL02')`
+
+	// database/sql join transaction begun using pool
+	trxCTX, err := ts.db.BeginTX(ctx)
+	assert.NoError(t, err, "failed to begin transaction")
+
+	_, err = db.ExecContext(trxCTX, `INSERT INTO users (id, name, balance) VALUES ($1, $2, $3)`, "USRSQL01", "Stdlib", 100)
+	assert.NoError(t, err, "failed to insert user using database/sql")
+
+	var total int
+	err = ts.db.QueryRow(trxCTX, count).Scan(&total)
+	assert.NoError(t, err, "failed to count users in transaction")
+	assert.Equal(t, 1, total, "pgx should see rows written by database/sql in the same transaction")
+
+	err = db.QueryRowContext(ctx, count).Scan(&total)
+	assert.NoError(t, err, "failed to count users outside transaction")
+	assert.Equal(t, 0, total, "database/sql outside transaction should not see uncommitted rows")
+
+	err = ts.db.RollbackTX(trxCTX)
+	assert.NoError(t, err, "failed to rollback transaction")
+
+	// transaction begun using *sql.DB is registered in the pool
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	assert.NoError(t, err, "failed to begin transaction using database/sql")
+	assert.Len(t, ts.db.ActiveTransactions(), 1, "transaction should be registered in the pool")
+
+	var isoLevel string
+	err = tx.QueryRowContext(ctx, `SHOW transaction_isolation`).Scan(&isoLevel)
+	assert.NoError(t, err, "failed to get transaction isolation")
+	assert.Equal(t, "serializable", isoLevel)
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO users (id, name, balance) VALUES ($1, $2, $3)`, "USRSQL02", "Stdlib", 200)
+	assert.NoError(t, err, "failed to insert user using database/sql transaction")
+
+	err = tx.Commit()
+	assert.NoError(t, err, "failed to commit transaction using database/sql")
+	assert.Len(t, ts.db.ActiveTransactions(), 0, "committed transaction should be removed from the pool")
+
+	err = ts.db.QueryRow(ctx, count).Scan(&total)
+	assert.NoError(t, err, "failed to count users after commit")
+	assert.Equal(t, 1, total, "committed rows should be visible")
+}
+
+// Outbox tests that outbox message is only relayed when its transaction committed
+// in order per aggregate key, and moved to dead letter after maximum attempts
+func (ts *TestSuite) Outbox(t *testing.T) {
+	ctx := context.Background()
+	box := outbox.New(ts.db,
+		outbox.WithTable("outbox_test"),
+		outbox.WithMaxAttempts(2),
+		outbox.WithBackoff(0, 0),
+	)
+
+	err := box.Migrate(ctx)
+	assert.NoError(t, err, "failed to migrate outbox")
+
+	err = ts.db.WithTransaction(ctx, func(ctx context.Context) error {
+		return box.Store(ctx,
+			outbox.Message{AggregateKey: "A", Topic: "A1", Payload: []byte("A1")},
+			outbox.Message{AggregateKey: "A", Topic: "A2", Payload: []byte("A2")},
+			outbox.Message{AggregateKey: "B", Topic: "B1", Payload: []byte("B1")},
+		)
+	})
+	assert.NoError(t, err, "failed to store outbox messages")
+
+	err = ts.db.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := box.Store(ctx, outbox.Message{AggregateKey: "C", Topic: "C1", Payload: []byte("C1")}); err != nil {
+			return err
+		}
+		return fmt.Errorf("rollback outbox message")
+	})
+	assert.Error(t, err, "transaction should be rolled back")
+
+	var published []string
+	publisher := outbox.PublisherFunc(func(ctx context.Context, msg outbox.Message) error {
+		published = append(published, msg.Topic)
+		if msg.AggregateKey == "B" {
+			return fmt.Errorf("broker unavailable")
+		}
+		return nil
+	})
+
+	// only the oldest message of each aggregate key is claimed
+	n, err := box.RelayOnce(ctx, publisher)
+	assert.NoError(t, err, "failed to relay outbox")
+	assert.Equal(t, 2, n)
+
+	// next message of aggregate A and retry of B1
+	n, err = box.RelayOnce(ctx, publisher)
+	assert.NoError(t, err, "failed to relay outbox")
+	assert.Equal(t, 2, n)
+
+	// nothing left, B1 moved to dead letter
+	n, err = box.RelayOnce(ctx, publisher)
+	assert.NoError(t, err, "failed to relay outbox")
+	assert.Equal(t, 0, n)
+
+	assert.Equal(t, []string{"A1", "B1", "A2", "B1"}, published)
+
+	var status string
+	err = ts.db.QueryRow(ctx, `SELECT status FROM outbox_test WHERE topic = 'B1'`).Scan(&status)
+	assert.NoError(t, err, "failed to get outbox status")
+	assert.Equal(t, string(outbox.StatusDead), status)
+}
+
 // CreateUser tests service CreateUser method
 func (ts *TestSuite) CreateUser(t *testing.T) {
 
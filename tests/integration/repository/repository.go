@@ -42,6 +42,11 @@ func (r *Repository) VerifyTX(ctx context.Context) error {
 	return r.db.VerifyTX(ctx)
 }
 
+// WithTransaction ---
+func (r *Repository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.db.WithTransaction(ctx, fn)
+}
+
 // CreateUser ---
 func (r *Repository) CreateUser(ctx context.Context, user model.User) error {
 
@@ -12,6 +12,7 @@ type Repository interface {
 	CommitTx(ctx context.Context) error
 	RollbackTx(ctx context.Context) error
 	VerifyTX(ctx context.Context) error
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 	CreateUser(ctx context.Context, user model.User) error
 	GetUsers(ctx context.Context) ([]model.User, error)
 	UpdateUserBalance(ctx context.Context, user model.User) error
@@ -0,0 +1,27 @@
+package tracing
+
+import "go.opentelemetry.io/otel/trace"
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	omitQueryText  bool
+}
+
+// Option is a function that can be used to configure the Tracer
+type Option func(*config)
+
+// WithTracerProvider will set tracer provider that used to create spans
+// default is global tracer provider
+func WithTracerProvider(tracerProvider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tracerProvider
+	}
+}
+
+// WithoutQueryText will omit SQL from statement spans
+// use it when SQL may contain sensitive literal values
+func WithoutQueryText() Option {
+	return func(c *config) {
+		c.omitQueryText = true
+	}
+}
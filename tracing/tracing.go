@@ -0,0 +1,255 @@
+// Package tracing trace transactions and statements of pgxtxpool.Pool using OpenTelemetry
+// every transaction get a span from BeginTX until CommitTX or RollbackTX,
+// and every statement executed inside the transaction get a child span of it
+// ex:
+//
+//	pool := pgxtxpool.New(
+//		pgxtxpool.SetHost("localhost", "5432"),
+//		tracing.WithTracing(tracing.WithTracerProvider(provider)),
+//	)
+//
+// only transaction that begun from pgxpool get a span,
+// statements inside savepoint and joined transaction use span of the transaction that own it
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	pgxtxpool "github.com/rasatmaja/pgx-txpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is name of the tracer that create the spans
+const instrumentationName = "github.com/rasatmaja/pgx-txpool/tracing"
+
+// span names
+const (
+	transactionSpanName = "pgxtxpool.transaction"
+	querySpanName       = "pgxtxpool.query"
+)
+
+// attributes that set on the spans
+const (
+	AttributeDBSystem       = attribute.Key("db.system.name")
+	AttributeQueryText      = attribute.Key("db.query.text")
+	AttributeSQLState       = attribute.Key("db.response.status_code")
+	AttributeTxID           = attribute.Key("pgxtxpool.tx_id")
+	AttributeIsolationLevel = attribute.Key("pgxtxpool.isolation_level")
+	AttributeOutcome        = attribute.Key("pgxtxpool.outcome")
+	AttributeRowsAffected   = attribute.Key("pgxtxpool.rows_affected")
+)
+
+// outcome of a transaction
+const (
+	OutcomeCommitted  = "committed"
+	OutcomeRolledBack = "rolled_back"
+	OutcomeError      = "error"
+)
+
+// defaultIsolationLevel is reported when transaction is begun without isolation level
+// then postgres use its default_transaction_isolation
+const defaultIsolationLevel = "default"
+
+// Tracer is a pgx.QueryTracer and pgxtxpool.Hooks that create spans for transactions and statements
+type Tracer struct {
+	pgxtxpool.NoopHooks
+
+	tracer       trace.Tracer
+	omitQueryTxt bool
+
+	// spans store span of every transaction that still in progress
+	spans sync.Map
+}
+
+var (
+	_ pgx.QueryTracer          = (*Tracer)(nil)
+	_ pgxtxpool.Hooks          = (*Tracer)(nil)
+	_ pgxtxpool.TxOptionsHooks = (*Tracer)(nil)
+)
+
+// New will create a Tracer
+// by default it use global tracer provider
+func New(opts ...Option) *Tracer {
+	config := config{
+		tracerProvider: otel.GetTracerProvider(),
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return &Tracer{
+		tracer:       config.tracerProvider.Tracer(instrumentationName),
+		omitQueryTxt: config.omitQueryText,
+	}
+}
+
+// WithTracing will create a Tracer and install it on the pool
+func WithTracing(opts ...Option) pgxtxpool.Option {
+	return pgxtxpool.WithQueryTracer(New(opts...))
+}
+
+// OnBegin will start span of the transaction
+// span start time is set to the time before transaction is begun
+func (t *Tracer) OnBegin(ctx context.Context, txID pgxtxpool.TxID, elapsed time.Duration) {
+	_, span := t.tracer.Start(ctx, transactionSpanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(time.Now().Add(-elapsed)),
+		trace.WithAttributes(
+			AttributeDBSystem.String("postgresql"),
+			AttributeTxID.String(string(txID)),
+		),
+	)
+	t.spans.Store(txID, span)
+}
+
+// OnTxOptions will set isolation level of the transaction on its span
+func (t *Tracer) OnTxOptions(ctx context.Context, txID pgxtxpool.TxID, txOptions pgx.TxOptions) {
+	span, ok := t.txSpan(txID)
+	if !ok {
+		return
+	}
+	isoLevel := string(txOptions.IsoLevel)
+	if isoLevel == "" {
+		isoLevel = defaultIsolationLevel
+	}
+	span.SetAttributes(AttributeIsolationLevel.String(isoLevel))
+}
+
+// AfterCommit will end span of the transaction as committed
+func (t *Tracer) AfterCommit(ctx context.Context, txID pgxtxpool.TxID, elapsed time.Duration) {
+	t.endTxSpan(txID, OutcomeCommitted, nil)
+}
+
+// AfterRollback will end span of the transaction as rolled back
+func (t *Tracer) AfterRollback(ctx context.Context, txID pgxtxpool.TxID, elapsed time.Duration) {
+	t.endTxSpan(txID, OutcomeRolledBack, nil)
+}
+
+// OnError will end span of the transaction with the error
+// when begin failed there is no span yet, then a span that only record the error is created
+func (t *Tracer) OnError(ctx context.Context, txID pgxtxpool.TxID, elapsed time.Duration, err error) {
+	if txID == "" {
+		_, span := t.tracer.Start(ctx, transactionSpanName,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithTimestamp(time.Now().Add(-elapsed)),
+			trace.WithAttributes(AttributeDBSystem.String("postgresql")),
+		)
+		span.SetAttributes(AttributeOutcome.String(OutcomeError))
+		recordError(span, err)
+		span.End()
+		return
+	}
+	t.endTxSpan(txID, OutcomeError, err)
+}
+
+// TraceQueryStart will start span of a statement
+// when context carry a transaction that has a span, statement span become its child
+// statement inside savepoint or joined transaction use span of the transaction that own it
+func (t *Tracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	attrs := []attribute.KeyValue{AttributeDBSystem.String("postgresql")}
+	if !t.omitQueryTxt {
+		attrs = append(attrs, AttributeQueryText.String(data.SQL))
+	}
+	if txID, ok := pgxtxpool.TxIDFromContext(ctx); ok {
+		attrs = append(attrs, AttributeTxID.String(string(txID)))
+	}
+	if ownerTxID, ok := pgxtxpool.OwnerTxIDFromContext(ctx); ok {
+		if span, ok := t.txSpan(ownerTxID); ok {
+			ctx = trace.ContextWithSpan(ctx, span)
+		}
+	}
+
+	ctx, _ = t.tracer.Start(ctx, operationName(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx
+}
+
+// TraceQueryEnd will end span of a statement
+// with rows affected when succeed or SQLSTATE when failed
+func (t *Tracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		recordError(span, data.Err)
+	} else {
+		span.SetAttributes(AttributeRowsAffected.Int64(data.CommandTag.RowsAffected()))
+	}
+	span.End()
+}
+
+// txSpan will get span of a transaction
+func (t *Tracer) txSpan(txID pgxtxpool.TxID) (trace.Span, bool) {
+	span, ok := t.spans.Load(txID)
+	if !ok {
+		return nil, false
+	}
+	return span.(trace.Span), true
+}
+
+// endTxSpan will end span of a transaction and remove it
+func (t *Tracer) endTxSpan(txID pgxtxpool.TxID, outcome string, err error) {
+	span, ok := t.spans.LoadAndDelete(txID)
+	if !ok {
+		return
+	}
+	txSpan := span.(trace.Span)
+	txSpan.SetAttributes(AttributeOutcome.String(outcome))
+	if err != nil {
+		recordError(txSpan, err)
+	}
+	txSpan.End()
+}
+
+// recordError will record error on a span
+// SQLSTATE is set when error come from postgres
+func recordError(span trace.Span, err error) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		span.SetAttributes(AttributeSQLState.String(pgErr.Code))
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// operationName will return name of statement span from its first keyword
+// leading comments are skipped, such as "-- name: GetUser :one" that sqlc generate
+// ex: "select * from users" will be named "SELECT"
+func operationName(sql string) string {
+	fields := strings.Fields(skipComments(sql))
+	if len(fields) == 0 {
+		return querySpanName
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// skipComments will remove line and block comments in front of sql
+func skipComments(sql string) string {
+	for {
+		sql = strings.TrimSpace(sql)
+		switch {
+		case strings.HasPrefix(sql, "--"):
+			_, rest, found := strings.Cut(sql, "\n")
+			if !found {
+				return ""
+			}
+			sql = rest
+		case strings.HasPrefix(sql, "/*"):
+			_, rest, found := strings.Cut(sql, "*/")
+			if !found {
+				return ""
+			}
+			sql = rest
+		default:
+			return sql
+		}
+	}
+}
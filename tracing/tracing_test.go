@@ -0,0 +1,200 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	pgxtxpool "github.com/rasatmaja/pgx-txpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTestTracer will create a Tracer that export spans to memory
+func newTestTracer(opts ...Option) (*Tracer, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return New(append([]Option{WithTracerProvider(provider)}, opts...)...), exporter
+}
+
+// attributeOf will return value of an attribute from a span
+func attributeOf(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+// spanByName will return the first span that has the name
+func spanByName(spans tracetest.SpanStubs, name string) (tracetest.SpanStub, bool) {
+	for _, span := range spans {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+
+func TestTracer(t *testing.T) {
+	t.Run("should create transaction span with statement as its child", func(t *testing.T) {
+		tracer, exporter := newTestTracer()
+		txID := pgxtxpool.TxID("tx-1")
+		ctx := (&pgxtxpool.Pool{}).ContextWithTxID(context.Background(), txID)
+
+		tracer.OnBegin(ctx, txID, time.Millisecond)
+		tracer.OnTxOptions(ctx, txID, pgx.TxOptions{IsoLevel: pgx.Serializable})
+
+		queryCTX := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "update users set balance = 0"})
+		tracer.TraceQueryEnd(queryCTX, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("UPDATE 3")})
+
+		tracer.AfterCommit(ctx, txID, 2*time.Millisecond)
+
+		spans := exporter.GetSpans()
+		txSpan, ok := spanByName(spans, transactionSpanName)
+		if !ok {
+			t.Log("transaction span is not exported")
+			t.FailNow()
+		}
+		querySpan, ok := spanByName(spans, "UPDATE")
+		if !ok {
+			t.Log("statement span is not exported")
+			t.FailNow()
+		}
+
+		if querySpan.Parent.SpanID() != txSpan.SpanContext.SpanID() {
+			t.Log("statement span should be child of transaction span")
+			t.FailNow()
+		}
+
+		expected := map[attribute.Key]attribute.Value{
+			AttributeTxID:           attribute.StringValue(string(txID)),
+			AttributeIsolationLevel: attribute.StringValue(string(pgx.Serializable)),
+			AttributeOutcome:        attribute.StringValue(OutcomeCommitted),
+		}
+		for key, value := range expected {
+			if got, _ := attributeOf(txSpan, key); got != value {
+				t.Logf("expected transaction span %s to be %v, got %v", key, value.Emit(), got.Emit())
+				t.FailNow()
+			}
+		}
+
+		if got, _ := attributeOf(querySpan, AttributeRowsAffected); got.AsInt64() != 3 {
+			t.Logf("expected rows affected 3, got %v", got.Emit())
+			t.FailNow()
+		}
+		if got, _ := attributeOf(querySpan, AttributeQueryText); got.AsString() != "update users set balance = 0" {
+			t.Logf("unexpected query text %v", got.Emit())
+			t.FailNow()
+		}
+	})
+
+	t.Run("should record outcome rolled back", func(t *testing.T) {
+		tracer, exporter := newTestTracer()
+		txID := pgxtxpool.TxID("tx-2")
+		ctx := (&pgxtxpool.Pool{}).ContextWithTxID(context.Background(), txID)
+
+		tracer.OnBegin(ctx, txID, 0)
+		tracer.OnTxOptions(ctx, txID, pgx.TxOptions{})
+		tracer.AfterRollback(ctx, txID, 0)
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Logf("expected 1 span, got %d", len(spans))
+			t.FailNow()
+		}
+		if got, _ := attributeOf(spans[0], AttributeOutcome); got.AsString() != OutcomeRolledBack {
+			t.Logf("expected outcome %s, got %v", OutcomeRolledBack, got.Emit())
+			t.FailNow()
+		}
+		if got, _ := attributeOf(spans[0], AttributeIsolationLevel); got.AsString() != defaultIsolationLevel {
+			t.Logf("expected isolation level %s, got %v", defaultIsolationLevel, got.Emit())
+			t.FailNow()
+		}
+	})
+
+	t.Run("should record SQLSTATE when statement and commit failed", func(t *testing.T) {
+		tracer, exporter := newTestTracer(WithoutQueryText())
+		txID := pgxtxpool.TxID("tx-3")
+		ctx := (&pgxtxpool.Pool{}).ContextWithTxID(context.Background(), txID)
+		pgErr := &pgconn.PgError{Code: "40001", Message: "could not serialize access"}
+
+		tracer.OnBegin(ctx, txID, 0)
+		queryCTX := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "select 1"})
+		tracer.TraceQueryEnd(queryCTX, nil, pgx.TraceQueryEndData{Err: pgErr})
+		tracer.OnError(ctx, txID, 0, pgErr)
+
+		for _, span := range exporter.GetSpans() {
+			if got, _ := attributeOf(span, AttributeSQLState); got.AsString() != "40001" {
+				t.Logf("expected %s span SQLSTATE 40001, got %v", span.Name, got.Emit())
+				t.FailNow()
+			}
+			if span.Status.Code != codes.Error {
+				t.Logf("expected %s span status error, got %v", span.Name, span.Status.Code)
+				t.FailNow()
+			}
+			if _, ok := attributeOf(span, AttributeQueryText); ok {
+				t.Logf("expected %s span without query text", span.Name)
+				t.FailNow()
+			}
+		}
+
+		txSpan, _ := spanByName(exporter.GetSpans(), transactionSpanName)
+		if got, _ := attributeOf(txSpan, AttributeOutcome); got.AsString() != OutcomeError {
+			t.Logf("expected outcome %s, got %v", OutcomeError, got.Emit())
+			t.FailNow()
+		}
+	})
+
+	t.Run("should record error span when begin failed", func(t *testing.T) {
+		tracer, exporter := newTestTracer()
+
+		tracer.OnError(context.Background(), "", time.Millisecond, errors.New("connection refused"))
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 || spans[0].Status.Code != codes.Error {
+			t.Logf("expected 1 error span, got %v", spans)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should create statement span without transaction", func(t *testing.T) {
+		tracer, exporter := newTestTracer()
+
+		queryCTX := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+		tracer.TraceQueryEnd(queryCTX, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 || spans[0].Name != "SELECT" || spans[0].Parent.IsValid() {
+			t.Logf("expected 1 root SELECT span, got %v", spans)
+			t.FailNow()
+		}
+		if _, ok := attributeOf(spans[0], AttributeTxID); ok {
+			t.Log("statement without transaction should not have tx id")
+			t.FailNow()
+		}
+	})
+}
+
+func TestOperationName(t *testing.T) {
+	cases := map[string]string{
+		"select * from users":          "SELECT",
+		"\n\tINSERT INTO users VALUES": "INSERT",
+		"":                             querySpanName,
+		"-- name: CreateUser :exec\nINSERT INTO users VALUES ($1)":  "INSERT",
+		"/* app */ -- name: GetUser :one\n  select name from users": "SELECT",
+		"-- only a comment": querySpanName,
+	}
+	for sql, expected := range cases {
+		if got := operationName(sql); got != expected {
+			t.Logf("expected operation name %s, got %s", expected, got)
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// WithTransaction will run fn inside a transaction
+// context that passed to fn carry the transaction id, so every query using that context join the transaction
+// if fn return nil then transaction will be committed
+// if fn return an error then transaction will be rolled back and the error will be returned
+// if fn panic then transaction will be rolled back and the panic will be re-thrown
+// in every case the transaction will be removed from the pool
+// if context already carry an active transaction then fn will run inside a savepoint
+func (p *Pool) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return p.withTransaction(ctx, PropagationNested, pgx.TxOptions{}, fn)
+}
+
+// WithTransactionPropagation will run fn like WithTransaction
+// but transaction is begun using BeginTXWithPropagation
+// ex: PropagationRequired will join active transaction in context,
+// so error from fn will mark that transaction as rollback only instead of rolling back a savepoint
+func (p *Pool) WithTransactionPropagation(ctx context.Context, propagation Propagation, txOptions pgx.TxOptions, fn func(ctx context.Context) error) error {
+	return p.withTransaction(ctx, propagation, txOptions, fn)
+}
+
+// withTransaction will run fn inside a transaction that begun using propagation and txOptions
+func (p *Pool) withTransaction(ctx context.Context, propagation Propagation, txOptions pgx.TxOptions, fn func(ctx context.Context) error) error {
+	txCTX, err := p.beginTX(ctx, propagation, txOptions)
+	if err != nil {
+		return err
+	}
+
+	// rollback transaction when fn panic
+	// then re-throw the panic to the caller
+	defer func() {
+		if r := recover(); r != nil {
+			_ = p.RollbackTX(txCTX)
+			panic(r)
+		}
+	}()
+
+	if err := fn(txCTX); err != nil {
+		if errRollback := p.RollbackTX(txCTX); errRollback != nil {
+			return errors.Join(err, errRollback)
+		}
+		return err
+	}
+
+	return p.CommitTX(txCTX)
+}
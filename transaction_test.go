@@ -0,0 +1,90 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithTransaction(t *testing.T) {
+	t.Run("should commit when fn return nil", func(t *testing.T) {
+		p, begun := newFakePool()
+
+		var txCTX context.Context
+		err := p.WithTransaction(context.Background(), func(ctx context.Context) error {
+			txCTX = ctx
+			_, err := p.Exec(ctx, "SELECT 1")
+			return err
+		})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		tx := (*begun)[0]
+		if !tx.called("Exec") || !tx.called("Commit") || tx.called("Rollback") {
+			t.Log("transaction should be used and committed")
+			t.FailNow()
+		}
+
+		if err := p.VerifyTX(txCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should rollback when fn return error", func(t *testing.T) {
+		p, begun := newFakePool()
+		expErr := errors.New("something went wrong")
+
+		var txCTX context.Context
+		err := p.WithTransaction(context.Background(), func(ctx context.Context) error {
+			txCTX = ctx
+			return expErr
+		})
+		if !errors.Is(err, expErr) {
+			t.Logf("expected error %v, got %v", expErr, err)
+			t.FailNow()
+		}
+
+		tx := (*begun)[0]
+		if !tx.called("Rollback") || tx.called("Commit") {
+			t.Log("transaction should be rolled back")
+			t.FailNow()
+		}
+
+		if err := p.VerifyTX(txCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should rollback and re-panic when fn panic", func(t *testing.T) {
+		p, begun := newFakePool()
+
+		var txCTX context.Context
+		func() {
+			defer func() {
+				if r := recover(); r != "boom" {
+					t.Logf("expected panic boom, got %v", r)
+					t.FailNow()
+				}
+			}()
+			p.WithTransaction(context.Background(), func(ctx context.Context) error {
+				txCTX = ctx
+				panic("boom")
+			})
+		}()
+
+		tx := (*begun)[0]
+		if !tx.called("Rollback") || tx.called("Commit") {
+			t.Log("transaction should be rolled back")
+			t.FailNow()
+		}
+
+		if err := p.VerifyTX(txCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	})
+}
@@ -0,0 +1,846 @@
+package pgxtxpool
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/multitracer"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// fakeTx is a pgx.Tx that only record which method has been called
+// so test can verify that query is routed to the transaction
+type fakeTx struct {
+	mx       sync.Mutex
+	calls    []string
+	children []*fakeTx
+}
+
+func (f *fakeTx) record(call string) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	f.calls = append(f.calls, call)
+}
+
+func (f *fakeTx) called(call string) bool {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	for _, c := range f.calls {
+		if c == call {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	f.record("Begin")
+	child := &fakeTx{}
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	f.children = append(f.children, child)
+	return child, nil
+}
+
+func (f *fakeTx) Commit(ctx context.Context) error {
+	f.record("Commit")
+	return nil
+}
+
+func (f *fakeTx) Rollback(ctx context.Context) error {
+	f.record("Rollback")
+	return nil
+}
+
+func (f *fakeTx) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	f.record("CopyFrom")
+	return 0, nil
+}
+
+func (f *fakeTx) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	f.record("SendBatch")
+	return &fakeBatchResults{}
+}
+
+func (f *fakeTx) LargeObjects() pgx.LargeObjects {
+	f.record("LargeObjects")
+	return pgx.LargeObjects{}
+}
+
+func (f *fakeTx) Prepare(ctx context.Context, name, sql string) (*pgconn.StatementDescription, error) {
+	f.record("Prepare")
+	return nil, nil
+}
+
+func (f *fakeTx) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	f.record("Exec")
+	return pgconn.CommandTag{}, nil
+}
+
+func (f *fakeTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	f.record("Query")
+	return &fakeRows{}, nil
+}
+
+func (f *fakeTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	f.record("QueryRow")
+	return &fakeRows{}
+}
+
+func (f *fakeTx) Conn() *pgx.Conn {
+	return nil
+}
+
+// fakeRows is an empty result of fakeTx, it is also used as pgx.Row
+type fakeRows struct {
+	pgx.Rows
+}
+
+func (r *fakeRows) Next() bool                    { return false }
+func (r *fakeRows) Close()                        {}
+func (r *fakeRows) Err() error                    { return nil }
+func (r *fakeRows) Scan(dest ...any) error        { return pgx.ErrNoRows }
+func (r *fakeRows) CommandTag() pgconn.CommandTag { return pgconn.CommandTag{} }
+
+// fakeBatchResults is an empty batch results of fakeTx
+type fakeBatchResults struct {
+	pgx.BatchResults
+}
+
+func (b *fakeBatchResults) Close() error { return nil }
+
+// newFakePool will create a pool that begin fake transaction
+// and return every transaction it has begun
+func newFakePool() (*Pool, *[]*fakeTx) {
+	var begun []*fakeTx
+	p := &Pool{generateID: generateID}
+	p.beginTx = func(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+		tx := &fakeTx{}
+		begun = append(begun, tx)
+		return tx, nil
+	}
+	return p, &begun
+}
+
+// newFakeTXContext will register a fake transaction to the pool
+// and return context that carry its transaction id
+func newFakeTXContext(p *Pool) (context.Context, *fakeTx) {
+	tx := &fakeTx{}
+	txID := generateID(context.Background())
+	p.storeTXConn(txID, &txConn{kind: TxKindTransaction, tx: tx, startedAt: time.Now(), lock: newTXLock()})
+	return p.ContextWithTxID(context.Background(), txID), tx
+}
+
+// txIDOf will return transaction id of the pool that carried in the context
+func txIDOf(p *Pool, ctx context.Context) TxID {
+	txID, _ := p.TxIDFromContext(ctx)
+	return txID
+}
+
+func TestQueryJoinTransaction(t *testing.T) {
+	cases := []struct {
+		name string
+		call func(ctx context.Context, p *Pool)
+	}{
+		{
+			name: "Exec",
+			call: func(ctx context.Context, p *Pool) { p.Exec(ctx, "SELECT 1") },
+		},
+		{
+			name: "Query",
+			call: func(ctx context.Context, p *Pool) { p.Query(ctx, "SELECT 1") },
+		},
+		{
+			name: "QueryRow",
+			call: func(ctx context.Context, p *Pool) { p.QueryRow(ctx, "SELECT 1") },
+		},
+		{
+			name: "SendBatch",
+			call: func(ctx context.Context, p *Pool) { p.SendBatch(ctx, &pgx.Batch{}) },
+		},
+		{
+			name: "CopyFrom",
+			call: func(ctx context.Context, p *Pool) {
+				p.CopyFrom(ctx, pgx.Identifier{"users"}, []string{"id"}, pgx.CopyFromRows(nil))
+			},
+		},
+		{
+			name: "Begin",
+			call: func(ctx context.Context, p *Pool) { p.Begin(ctx) },
+		},
+		{
+			name: "BeginTx",
+			call: func(ctx context.Context, p *Pool) { p.BeginTx(ctx, pgx.TxOptions{}) },
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := &Pool{generateID: generateID}
+			ctx, tx := newFakeTXContext(p)
+
+			c.call(ctx, p)
+
+			// BeginTx will start a savepoint using Begin from transaction
+			expCall := c.name
+			if expCall == "BeginTx" {
+				expCall = "Begin"
+			}
+			if !tx.called(expCall) {
+				t.Logf("%s is not routed to transaction", c.name)
+				t.FailNow()
+			}
+		})
+	}
+}
+
+func TestNestedBeginTX(t *testing.T) {
+	t.Run("should create savepoint and release it on commit", func(t *testing.T) {
+		p := &Pool{generateID: generateID}
+		outerCTX, outer := newFakeTXContext(p)
+
+		innerCTX, err := p.BeginTX(outerCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if !outer.called("Begin") || len(outer.children) != 1 {
+			t.Log("nested BeginTX should create savepoint from outer transaction")
+			t.FailNow()
+		}
+
+		if txIDOf(p, innerCTX) == txIDOf(p, outerCTX) {
+			t.Log("nested BeginTX should create new transaction id")
+			t.FailNow()
+		}
+
+		// query with inner context should be routed to savepoint
+		p.Exec(innerCTX, "SELECT 1")
+		if !outer.children[0].called("Exec") || outer.called("Exec") {
+			t.Log("query with inner context should be routed to savepoint")
+			t.FailNow()
+		}
+
+		if err := p.CommitTX(innerCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if !outer.children[0].called("Commit") || outer.called("Commit") {
+			t.Log("commit with inner context should only release savepoint")
+			t.FailNow()
+		}
+
+		// outer transaction should still usable
+		if _, ok := p.getTXFromContext(outerCTX); !ok {
+			t.Log("outer transaction should still exists in pool")
+			t.FailNow()
+		}
+		p.Exec(outerCTX, "SELECT 1")
+		if !outer.called("Exec") {
+			t.Log("query with outer context should be routed to outer transaction")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should rollback to savepoint on rollback", func(t *testing.T) {
+		p := &Pool{generateID: generateID}
+		outerCTX, outer := newFakeTXContext(p)
+
+		innerCTX, err := p.BeginTX(outerCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if err := p.RollbackTX(innerCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if !outer.children[0].called("Rollback") || outer.called("Rollback") {
+			t.Log("rollback with inner context should only rollback to savepoint")
+			t.FailNow()
+		}
+
+		if err := p.VerifyTX(innerCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if _, ok := p.getTXFromContext(outerCTX); !ok {
+			t.Log("outer transaction should still exists in pool")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should remove savepoints when outer transaction is committed", func(t *testing.T) {
+		p := &Pool{generateID: generateID}
+		outerCTX, outer := newFakeTXContext(p)
+
+		innerCTX, err := p.BeginTX(outerCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		deepestCTX, err := p.BeginTX(innerCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if err := p.CommitTX(outerCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if len(p.ActiveTransactions()) != 0 {
+			t.Logf("savepoints should be removed along with outer transaction, got %v", p.ActiveTransactions())
+			t.FailNow()
+		}
+
+		for _, ctx := range []context.Context{deepestCTX, innerCTX} {
+			if err := p.CommitTX(ctx); !errors.Is(err, ErrTxPoolNotFound) {
+				t.Logf("expected error %v, got %v", ErrTxPoolNotFound, err)
+				t.FailNow()
+			}
+		}
+		if outer.children[0].called("Commit") || outer.children[0].children[0].called("Commit") {
+			t.Log("savepoint should not be released after outer transaction is committed")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should remove savepoints of savepoint when it is rolled back", func(t *testing.T) {
+		p := &Pool{generateID: generateID}
+		outerCTX, _ := newFakeTXContext(p)
+
+		innerCTX, err := p.BeginTX(outerCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		deepestCTX, err := p.BeginTX(innerCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if err := p.RollbackTX(innerCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err := p.CommitTX(deepestCTX); !errors.Is(err, ErrTxPoolNotFound) {
+			t.Logf("expected error %v, got %v", ErrTxPoolNotFound, err)
+			t.FailNow()
+		}
+		if _, ok := p.getTXFromContext(outerCTX); !ok {
+			t.Log("outer transaction should still exists in pool")
+			t.FailNow()
+		}
+	})
+}
+
+func TestBeginTXWithOptions(t *testing.T) {
+	serializable := pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.Deferrable,
+	}
+
+	t.Run("should store options and return it from context", func(t *testing.T) {
+		p, begun := newFakePool()
+		ctx, err := p.BeginTXWithOptions(context.Background(), serializable)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if len(*begun) != 1 {
+			t.Log("transaction should be begun from pool")
+			t.FailNow()
+		}
+
+		opts, err := p.TxOptions(ctx)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if opts != serializable {
+			t.Logf("expected options %+v, got %+v", serializable, opts)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should inherit options on nested transaction", func(t *testing.T) {
+		p, _ := newFakePool()
+		outerCTX, err := p.BeginTXWithOptions(context.Background(), serializable)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		innerCTX, err := p.BeginTX(outerCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		opts, err := p.TxOptions(innerCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if opts != serializable {
+			t.Logf("expected options %+v, got %+v", serializable, opts)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should return error when nested options mismatch", func(t *testing.T) {
+		p, _ := newFakePool()
+		outerCTX, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		_, err = p.BeginTXWithOptions(outerCTX, serializable)
+		if !errors.Is(err, ErrTxPoolOptionsMismatch) {
+			t.Logf("expected error %v, got %v", ErrTxPoolOptionsMismatch, err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should return error when transaction not found", func(t *testing.T) {
+		p, _ := newFakePool()
+		if _, err := p.TxOptions(context.Background()); !errors.Is(err, ErrTxPoolIDNotFound) {
+			t.Logf("expected error %v, got %v", ErrTxPoolIDNotFound, err)
+			t.FailNow()
+		}
+
+		ctx := p.ContextWithTxID(context.Background(), generateID(context.Background()))
+		if _, err := p.TxOptions(ctx); !errors.Is(err, ErrTxPoolNotFound) {
+			t.Logf("expected error %v, got %v", ErrTxPoolNotFound, err)
+			t.FailNow()
+		}
+	})
+}
+
+// waitUntil will wait until condition is true or timeout
+func waitUntil(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Log("timeout waiting condition")
+			t.FailNow()
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAbortByContext(t *testing.T) {
+	t.Run("should rollback transaction when context is cancelled", func(t *testing.T) {
+		p, begun := newFakePool()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		txCTX, err := p.BeginTX(ctx)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		cancel()
+
+		tx := (*begun)[0]
+		waitUntil(t, func() bool { return tx.called("Rollback") })
+
+		if err := p.VerifyTX(txCTX); err != nil {
+			t.Log("transaction should be removed from pool")
+			t.FailNow()
+		}
+
+		err = p.CommitTX(txCTX)
+		if !errors.Is(err, ErrTxPoolAbortedByContext) || !errors.Is(err, context.Canceled) {
+			t.Logf("expected error %v, got %v", ErrTxPoolAbortedByContext, err)
+			t.FailNow()
+		}
+
+		// cause is only reported once
+		if err := p.RollbackTX(txCTX); !errors.Is(err, ErrTxPoolNotFound) {
+			t.Logf("expected error %v, got %v", ErrTxPoolNotFound, err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should remove savepoint and joined transaction along with aborted transaction", func(t *testing.T) {
+		p, begun := newFakePool()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		txCTX, err := p.BeginTX(ctx)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		savepointCTX, err := p.BeginTX(txCTX)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		joinedCTX, err := p.BeginTXWithPropagation(savepointCTX, PropagationRequired, pgx.TxOptions{})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		cancel()
+		waitUntil(t, func() bool { return len(p.ActiveTransactions()) == 0 })
+
+		tx := (*begun)[0]
+		if !tx.called("Rollback") || tx.children[0].called("Rollback") {
+			t.Log("only transaction that own the connection should be rolled back")
+			t.FailNow()
+		}
+		for _, ctx := range []context.Context{joinedCTX, savepointCTX} {
+			if err := p.CommitTX(ctx); !errors.Is(err, ErrTxPoolAbortedByContext) {
+				t.Logf("expected error %v, got %v", ErrTxPoolAbortedByContext, err)
+				t.FailNow()
+			}
+		}
+	})
+
+	t.Run("should rollback transaction when deadline passes", func(t *testing.T) {
+		p, begun := newFakePool()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+
+		txCTX, err := p.BeginTX(ctx)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		tx := (*begun)[0]
+		waitUntil(t, func() bool { return tx.called("Rollback") })
+
+		err = p.RollbackTX(txCTX)
+		if !errors.Is(err, ErrTxPoolAbortedByContext) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Logf("expected error %v, got %v", ErrTxPoolAbortedByContext, err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should stop watching context after commit", func(t *testing.T) {
+		p, begun := newFakePool()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		txCTX, err := p.BeginTX(ctx)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		if err := p.CommitTX(txCTX); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+
+		if (*begun)[0].called("Rollback") {
+			t.Log("committed transaction should not be rolled back")
+			t.FailNow()
+		}
+
+		if _, ok := p.aborted.Load(txIDOf(p, txCTX)); ok {
+			t.Log("committed transaction should not have abort cause")
+			t.FailNow()
+		}
+	})
+}
+
+func TestNewWithContext(t *testing.T) {
+	t.Run("should create pool without connecting to database", func(t *testing.T) {
+		p, err := NewWithContext(context.Background(), SetHost("localhost", "5432"), WithMaxConns(2))
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		defer p.Close()
+
+		if maxConns := p.Stat().MaxConns(); maxConns != 2 {
+			t.Logf("expected max conns 2, got %d", maxConns)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should return joined error of invalid options", func(t *testing.T) {
+		p, err := NewWithContext(context.Background(), WithMaxConns(0), WithSSLMode("XXXX"))
+		if p != nil || !errors.Is(err, ErrTxPoolInvalidConfig) {
+			t.Logf("expected error %v, got %v", ErrTxPoolInvalidConfig, err)
+			t.FailNow()
+		}
+		if !strings.Contains(err.Error(), "max conns") || !strings.Contains(err.Error(), "sslmode") {
+			t.Logf("expected every invalid option in error, got %v", err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should return error when ping failed", func(t *testing.T) {
+		// nothing should listen on port 1
+		p, err := NewWithContext(context.Background(),
+			SetHost("127.0.0.1", "1"),
+			WithSSLMode("disable"),
+			WithPing(time.Second),
+		)
+		if p != nil || !errors.Is(err, ErrTxPoolPingFailed) {
+			t.Logf("expected error %v, got %v", ErrTxPoolPingFailed, err)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should panic on invalid options using New", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Log("expected panic")
+				t.FailNow()
+			}
+		}()
+		New(WithMaxConns(0))
+	})
+}
+
+func TestFromDSN(t *testing.T) {
+	t.Run("should create pool from dsn", func(t *testing.T) {
+		p, err := FromDSN(context.Background(), "postgres://postgres@localhost:5432/postgres?pool_max_conns=3",
+			WithMaxTxLifetime(time.Minute),
+			WithHooks(NoopHooks{}),
+		)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		defer p.Close()
+
+		if maxConns := p.Stat().MaxConns(); maxConns != 3 {
+			t.Logf("expected max conns 3, got %d", maxConns)
+			t.FailNow()
+		}
+		if p.reaper == nil || len(p.hooks) != 1 {
+			t.Log("expected reaper and hooks to be configured")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should install tracer from options", func(t *testing.T) {
+		tracer := &recordQueryTracer{}
+		p, err := FromDSN(context.Background(), "postgres://postgres@localhost:5432/postgres", WithQueryTracer(tracer))
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		defer p.Close()
+
+		if p.Config().ConnConfig.Tracer != tracer {
+			t.Logf("expected tracer from options, got %T", p.Config().ConnConfig.Tracer)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should return every problem of dsn and options", func(t *testing.T) {
+		_, err := FromDSN(context.Background(), "postgres://localhost:5432/postgres?sslmode=XXXX", SetHost("localhost", "5432"))
+		if !errors.Is(err, ErrTxPoolInvalidConfig) {
+			t.Logf("expected error %v, got %v", ErrTxPoolInvalidConfig, err)
+			t.FailNow()
+		}
+		if errs := err.(interface{ Unwrap() []error }).Unwrap(); len(errs) != 2 {
+			t.Logf("expected 2 errors, got %v", err)
+			t.FailNow()
+		}
+	})
+}
+
+func TestFromConfig(t *testing.T) {
+	t.Run("should keep tracer from pgxpool config and add tracer from options", func(t *testing.T) {
+		pgxConfig, err := pgxpool.ParseConfig("postgres://postgres@localhost:5432/postgres")
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		tracer := &recordQueryTracer{}
+		pgxConfig.ConnConfig.Tracer = tracer
+
+		p, err := FromConfig(context.Background(), pgxConfig, WithQueryTracer(&recordQueryTracer{}))
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		defer p.Close()
+
+		if _, ok := p.Config().ConnConfig.Tracer.(*multitracer.Tracer); !ok {
+			t.Logf("expected multitracer, got %T", p.Config().ConnConfig.Tracer)
+			t.FailNow()
+		}
+		if pgxConfig.ConnConfig.Tracer != tracer {
+			t.Log("pgxpool config should not be modified")
+			t.FailNow()
+		}
+		if len(p.hooks) != 1 {
+			t.Log("tracer that implement hooks should be added as hooks")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should return error when config is nil", func(t *testing.T) {
+		if _, err := FromConfig(context.Background(), nil); !errors.Is(err, ErrTxPoolInvalidConfig) {
+			t.Logf("expected error %v, got %v", ErrTxPoolInvalidConfig, err)
+			t.FailNow()
+		}
+	})
+}
+
+func TestWrap(t *testing.T) {
+	t.Run("should wrap existing pgxpool", func(t *testing.T) {
+		pool, err := pgxpool.New(context.Background(), "postgres://postgres@localhost:5432/postgres")
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		p, err := Wrap(context.Background(), pool, WithLeakDetection())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		defer p.Close()
+
+		if p.Pool != pool || !p.leakDetection {
+			t.Log("expected pool to be wrapped with options")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should reject options that configure connections", func(t *testing.T) {
+		pool, err := pgxpool.New(context.Background(), "postgres://postgres@localhost:5432/postgres")
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		defer pool.Close()
+
+		_, err = Wrap(context.Background(), pool, WithMaxConns(10), WithQueryTracer(&recordQueryTracer{}))
+		if errs := err.(interface{ Unwrap() []error }).Unwrap(); len(errs) != 2 {
+			t.Logf("expected 2 errors, got %v", err)
+			t.FailNow()
+		}
+	})
+}
+
+func TestIDGenerator(t *testing.T) {
+	type requestIDKey struct{}
+
+	t.Run("should generate transaction id from context", func(t *testing.T) {
+		p, _ := newFakePool()
+		p.generateID = func(ctx context.Context) TxID {
+			return TxID("tx-" + ctx.Value(requestIDKey{}).(string))
+		}
+
+		ctx, err := p.BeginTX(context.WithValue(context.Background(), requestIDKey{}, "req-1"))
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if txID := txIDOf(p, ctx); txID != "tx-req-1" {
+			t.Logf("expected transaction id tx-req-1, got %s", txID)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should reject duplicate transaction id", func(t *testing.T) {
+		p, begun := newFakePool()
+		hooks := &recordHooks{}
+		p.hooks = multiHooks{hooks}
+		p.generateID = func(ctx context.Context) TxID { return "fixed" }
+
+		first, err := p.BeginTX(context.Background())
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		_, err = p.BeginTX(context.Background())
+		if !errors.Is(err, ErrTxPoolInvalidID) {
+			t.Logf("expected error %v, got %v", ErrTxPoolInvalidID, err)
+			t.FailNow()
+		}
+		if !(*begun)[1].called("Rollback") {
+			t.Log("rejected transaction should be rolled back")
+			t.FailNow()
+		}
+
+		// savepoint with duplicate id is rolled back too
+		_, err = p.BeginTX(first)
+		if !errors.Is(err, ErrTxPoolInvalidID) || !(*begun)[0].children[0].called("Rollback") {
+			t.Logf("expected savepoint to be rejected and rolled back, got %v", err)
+			t.FailNow()
+		}
+
+		// registered transaction is not overwritten
+		if conn, ok := p.getTXConn("fixed"); !ok || conn.tx != (*begun)[0] {
+			t.Log("first transaction should still be registered")
+			t.FailNow()
+		}
+		if err := p.CommitTX(first); err != nil || !(*begun)[0].called("Commit") {
+			t.Logf("first transaction should be committed, got %v", err)
+			t.FailNow()
+		}
+
+		expCalls := []string{"OnBegin", "OnError", "OnError", "BeforeCommit", "AfterCommit"}
+		if calls := hooks.recorded(); !slices.Equal(calls, expCalls) {
+			t.Logf("expected calls %v, got %v", expCalls, calls)
+			t.FailNow()
+		}
+	})
+
+	t.Run("should reject empty transaction id", func(t *testing.T) {
+		p, _ := newFakePool()
+		p.generateID = func(ctx context.Context) TxID { return "" }
+
+		if _, err := p.BeginTX(context.Background()); !errors.Is(err, ErrTxPoolInvalidID) {
+			t.Logf("expected error %v, got %v", ErrTxPoolInvalidID, err)
+			t.FailNow()
+		}
+		if len(p.ActiveTransactions()) != 0 {
+			t.Log("transaction should not be registered")
+			t.FailNow()
+		}
+	})
+
+	t.Run("should not abort registered transaction when rejected context is done", func(t *testing.T) {
+		p, begun := newFakePool()
+		p.generateID = func(ctx context.Context) TxID { return "fixed" }
+
+		first, _ := p.BeginTX(context.Background())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := p.BeginTX(ctx); !errors.Is(err, ErrTxPoolInvalidID) {
+			t.Logf("expected error %v, got %v", ErrTxPoolInvalidID, err)
+			t.FailNow()
+		}
+
+		if err := p.CommitTX(first); err != nil || (*begun)[0].called("Rollback") {
+			t.Logf("first transaction should not be aborted, got %v", err)
+			t.FailNow()
+		}
+	})
+}
@@ -1,8 +1,13 @@
 package pgxtxpool
 
-import "github.com/google/uuid"
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
 
 // generateID will generate unique id for transaction ID
-func generateID() TxID {
+// it is default id generator, see WithIDGenerator
+func generateID(ctx context.Context) TxID {
 	return TxID(uuid.New().String())
 }